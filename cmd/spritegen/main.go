@@ -0,0 +1,216 @@
+// Comando spritegen: empacota os PNGs indexados de um diretório num atlas
+// 2BPP e gera o arquivo Go com os bytes e as medidas de cada sprite.
+//
+// Cada PNG vira um sprite. O nome define o identificador e, opcionalmente,
+// o tamanho do quadro: "player.8x12.png" é SPRITE_PLAYER com quadros de 8x12
+// lado a lado; sem o tamanho, a imagem inteira é um único quadro. Só os
+// índices 0-3 da paleta são aceitos, e eles vão direto para o valor 2BPP
+// (o índice 0 é transparente com as cores de desenho usadas no jogo).
+//
+//    go run ./cmd/spritegen -in assets/sprites -out src/sprites_gen.go
+package main
+
+import (
+    "bytes"
+    "flag"
+    "fmt"
+    "image"
+    "image/png"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+type sprite struct {
+    name          string // Nome da constante, ex.: PLAYER
+    width, height int    // Tamanho de um quadro
+    frames        int
+    pixels        *image.Paletted
+
+    x, y int // Posição no atlas
+}
+
+type atlas struct {
+    width, height int
+    data          []uint8
+}
+
+func main() {
+    in := flag.String("in", "assets/sprites", "diretório com os PNGs indexados")
+    out := flag.String("out", "src/sprites_gen.go", "arquivo Go gerado")
+    pkg := flag.String("package", "main", "pacote do arquivo gerado")
+    flag.Parse()
+
+    sprites, err := loadSprites(*in)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "spritegen:", err)
+        os.Exit(1)
+    }
+    a := pack(sprites)
+    src := generate(*pkg, sprites, a)
+    if err := os.WriteFile(*out, src, 0o644); err != nil {
+        fmt.Fprintln(os.Stderr, "spritegen:", err)
+        os.Exit(1)
+    }
+    fmt.Printf("%s: %d sprites, atlas %dx%d (%d bytes)\n", *out, len(sprites), a.width, a.height, len(a.data))
+}
+
+// Lê todos os PNGs do diretório, em ordem alfabética
+func loadSprites(dir string) ([]*sprite, error) {
+    paths, err := filepath.Glob(filepath.Join(dir, "*.png"))
+    if err != nil {
+        return nil, err
+    }
+    if len(paths) == 0 {
+        return nil, fmt.Errorf("nenhum PNG em %s", dir)
+    }
+    sort.Strings(paths)
+
+    var sprites []*sprite
+    seen := map[string]bool{}
+    for _, path := range paths {
+        s, err := loadSprite(path)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %v", path, err)
+        }
+        if seen[s.name] {
+            return nil, fmt.Errorf("%s: sprite %s repetido", path, s.name)
+        }
+        seen[s.name] = true
+        sprites = append(sprites, s)
+    }
+    return sprites, nil
+}
+
+func loadSprite(path string) (*sprite, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    img, err := png.Decode(f)
+    if err != nil {
+        return nil, err
+    }
+    return newSprite(filepath.Base(path), img)
+}
+
+func newSprite(file string, img image.Image) (*sprite, error) {
+    pixels, ok := img.(*image.Paletted)
+    if !ok {
+        return nil, fmt.Errorf("a imagem não é indexada")
+    }
+    bounds := pixels.Bounds()
+    for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+        for x := bounds.Min.X; x < bounds.Max.X; x++ {
+            if index := pixels.ColorIndexAt(x, y); index > 3 {
+                return nil, fmt.Errorf("pixel (%d, %d) usa o índice %d; só 0-3 cabem em 2BPP", x, y, index)
+            }
+        }
+    }
+
+    base := strings.TrimSuffix(file, ".png")
+    s := &sprite{pixels: pixels, width: bounds.Dx(), height: bounds.Dy(), frames: 1}
+    if dot := strings.LastIndexByte(base, '.'); dot >= 0 {
+        w, h, err := parseSize(base[dot+1:])
+        if err != nil {
+            return nil, err
+        }
+        if w == 0 || h != bounds.Dy() || bounds.Dx()%w != 0 {
+            return nil, fmt.Errorf("quadros de %dx%d não dividem a imagem de %dx%d", w, h, bounds.Dx(), bounds.Dy())
+        }
+        s.width, s.height, s.frames = w, h, bounds.Dx()/w
+        base = base[:dot]
+    }
+    s.name = strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(base))
+    return s, nil
+}
+
+// "8x12" -> 8, 12
+func parseSize(text string) (int, int, error) {
+    parts := strings.Split(text, "x")
+    if len(parts) == 2 {
+        w, errW := strconv.Atoi(parts[0])
+        h, errH := strconv.Atoi(parts[1])
+        if errW == nil && errH == nil {
+            return w, h, nil
+        }
+    }
+    return 0, 0, fmt.Errorf("tamanho de quadro inválido %q (use LARGURAxALTURA)", text)
+}
+
+// Empilha os sprites, um por linha do atlas, com os quadros lado a lado.
+// A largura é arredondada para 4 pixels para cada linha começar num byte.
+func pack(sprites []*sprite) atlas {
+    var a atlas
+    for _, s := range sprites {
+        s.x, s.y = 0, a.height
+        a.height += s.height
+        if w := s.width * s.frames; w > a.width {
+            a.width = w
+        }
+    }
+    a.width = (a.width + 3) &^ 3
+    a.data = make([]uint8, a.width*a.height/4)
+
+    for _, s := range sprites {
+        bounds := s.pixels.Bounds()
+        for y := 0; y < bounds.Dy(); y++ {
+            for x := 0; x < bounds.Dx(); x++ {
+                index := s.pixels.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y)
+                bit := (s.y+y)*a.width + s.x + x
+                a.data[bit>>2] |= index << (6 - uint(bit&3)*2)
+            }
+        }
+    }
+    return a
+}
+
+func generate(pkg string, sprites []*sprite, a atlas) []byte {
+    var b bytes.Buffer
+    b.WriteString("// Code generated by cmd/spritegen; DO NOT EDIT.\n\n")
+    fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+    b.WriteString("const (\n")
+    fmt.Fprintf(&b, "    SPRITE_ATLAS_WIDTH = %d\n", a.width)
+    fmt.Fprintf(&b, "    SPRITE_ATLAS_HEIGHT = %d\n", a.height)
+    fmt.Fprintf(&b, "    SPRITE_COUNT = %d\n", len(sprites))
+    for i, s := range sprites {
+        b.WriteString("\n")
+        fmt.Fprintf(&b, "    SPRITE_%s = %d\n", s.name, i)
+        fmt.Fprintf(&b, "    SPRITE_%s_WIDTH = %d\n", s.name, s.width)
+        fmt.Fprintf(&b, "    SPRITE_%s_HEIGHT = %d\n", s.name, s.height)
+        fmt.Fprintf(&b, "    SPRITE_%s_FRAMES = %d\n", s.name, s.frames)
+    }
+    b.WriteString(")\n\n")
+
+    b.WriteString("// Posição no atlas e medidas de cada sprite\n")
+    b.WriteString("var spriteInfo = [SPRITE_COUNT]struct {\n")
+    b.WriteString("    x, y, width, height, frames uint8\n")
+    b.WriteString("}{\n")
+    for _, s := range sprites {
+        fmt.Fprintf(&b, "    SPRITE_%s: {%d, %d, %d, %d, %d},\n", s.name, s.x, s.y, s.width, s.height, s.frames)
+    }
+    b.WriteString("}\n\n")
+
+    b.WriteString("// Atlas 2BPP, linhas de SPRITE_ATLAS_WIDTH pixels\n")
+    b.WriteString("var spriteAtlas = [...]uint8{\n")
+    for i := 0; i < len(a.data); i += 16 {
+        b.WriteString("    ")
+        end := i + 16
+        if end > len(a.data) {
+            end = len(a.data)
+        }
+        for j := i; j < end; j++ {
+            if j > i {
+                b.WriteString(" ")
+            }
+            fmt.Fprintf(&b, "0x%02x,", a.data[j])
+        }
+        b.WriteString("\n")
+    }
+    b.WriteString("}\n")
+    return b.Bytes()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+    "image"
+    "image/color"
+    "testing"
+)
+
+var testPalette = color.Palette{color.Black, color.White, color.Gray{0x40}, color.Gray{0x80}, color.Gray{0xc0}}
+
+func TestPack(t *testing.T) {
+    // Dois quadros de 2x1: índices 1 2 | 3 0
+    img := image.NewPaletted(image.Rect(0, 0, 4, 1), testPalette)
+    copy(img.Pix, []uint8{1, 2, 3, 0})
+    anim, err := newSprite("anim.2x1.png", img)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if anim.name != "ANIM" || anim.width != 2 || anim.height != 1 || anim.frames != 2 {
+        t.Fatalf("sprite = %s %dx%d x%d", anim.name, anim.width, anim.height, anim.frames)
+    }
+
+    dot := image.NewPaletted(image.Rect(0, 0, 1, 1), testPalette)
+    dot.Pix[0] = 3
+    single, err := newSprite("big-dot.png", dot)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if single.name != "BIG_DOT" || single.frames != 1 {
+        t.Fatalf("sprite = %s x%d", single.name, single.frames)
+    }
+
+    a := pack([]*sprite{anim, single})
+    if a.width != 4 || a.height != 2 || single.y != 1 {
+        t.Fatalf("atlas %dx%d, BIG_DOT em y=%d", a.width, a.height, single.y)
+    }
+    want := []uint8{0b01_10_11_00, 0b11_00_00_00}
+    for i := range want {
+        if a.data[i] != want[i] {
+            t.Errorf("data[%d] = %08b, want %08b", i, a.data[i], want[i])
+        }
+    }
+}
+
+func TestNewSpriteErrors(t *testing.T) {
+    img := image.NewPaletted(image.Rect(0, 0, 4, 2), testPalette)
+    tests := []struct {
+        file string
+        index uint8
+    }{
+        {"cor.png", 4},         // Índice fora do 2BPP
+        {"quadro.3x2.png", 0},  // Largura não divide a imagem
+        {"quadro.4x1.png", 0},  // Altura diferente da imagem
+        {"quadro.4y2.png", 0},  // Tamanho mal escrito
+    }
+    for _, tt := range tests {
+        img.Pix[0] = tt.index
+        if _, err := newSprite(tt.file, img); err == nil {
+            t.Errorf("%s: esperava erro", tt.file)
+        }
+    }
+    if _, err := newSprite("rgba.png", image.NewRGBA(image.Rect(0, 0, 1, 1))); err == nil {
+        t.Errorf("imagem não indexada foi aceita")
+    }
+}
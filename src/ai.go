@@ -0,0 +1,332 @@
+package main
+
+// IA dos inimigos. Cada inimigo está num estado (aiState) e, enquanto
+// patrulha ou se aproxima, escolhe o próximo a cada quadro olhando o
+// jogador da frente (leadPlayer): a distância até ele e para onde ele está
+// mirando. Os números de cada tipo ficam em enemyBehaviors; um tipo com
+// retreatFrames, hop, dive ou cover zerado nunca entra naquele estado.
+//
+//   AI_PATROL: anda para a esquerda; o voador ondula. É o estado de quem
+//      ainda não viu o jogador ou já passou dele, e o de todo inimigo com
+//      mais de AI_GIVE_UP_AGE quadros, para que nenhum segure uma das
+//      vagas de MAX_ENEMIES para sempre.
+//   AI_APPROACH: jogador a menos de sense pixels à esquerda: vai até ele
+//      mais depressa e para a keep pixels; o voador paira acima dele.
+//   AI_RETREAT: mirado de perto: recua para a direita por um tempo.
+//   AI_COVER: mirado com uma pedra ou espeto entre ele e o jogador: para
+//      logo atrás do obstáculo, onde tiros retos ou subindo vindos da
+//      esquerda batem nele (resolveHit). Tiros de cima ainda acertam.
+//   AI_DIVE: voador acima do jogador mergulha nele e depois sobe.
+//   AI_HOP: terrestre pula a beira de um buraco ou, às vezes, um tiro.
+//
+// Depois de recuar, se esconder ou mergulhar, o inimigo descansa
+// AI_REST_FRAMES antes de repetir. O que é sorteado sai de aiRand.
+const (
+    // Estados
+    AI_PATROL = 0
+    AI_APPROACH = 1
+    AI_RETREAT = 2
+    AI_COVER = 3
+    AI_DIVE = 4
+    AI_HOP = 5
+
+    AI_GIVE_UP_AGE = 600
+    AI_REST_FRAMES = 60
+    AI_AIM_CONE = 2     // Mirado se o desvio da mira é até metade da distância (~26°)
+    AI_COVER_RANGE = 40 // Distância máxima até o esconderijo
+    AI_COVER_FRAMES = 90
+    AI_DODGE_RANGE = 24 // Distância do tiro em que se decide pular
+    AI_DODGE_BAND = 8   // Largura da faixa, para decidir uma vez por tiro
+    AI_LAND_REACH = TERRAIN_TILE // Quanto abaixo do chão o pulo ainda pousa
+
+    // Voadores
+    AI_FLY_TOP = 40
+    AI_FLY_BOTTOM = GROUND_Y - 30
+    AI_HOVER = 36      // Altura acima do jogador em que paira
+    AI_DIVE_RANGE = 16 // Distância horizontal para mergulhar
+    AI_DIVE_FRAMES = 90
+)
+
+type enemyBehavior struct {
+    // Velocidades em quartos de currentEnemySpeed
+    patrol, approach, retreat, hopSpeed uint8
+    sense int16 // Distância em que nota o jogador
+    keep int16  // Distância em que para ao se aproximar
+    retreatFrames uint8 // Recuo mais longo; o sorteado fica entre a metade e isso
+    hop int16   // Impulso do pulo, ponto fixo 8.8
+    dodge int8  // Chance (%) de pular um tiro
+    dive int16  // Velocidade do mergulho, ponto fixo 8.8
+    cover bool
+}
+
+var enemyBehaviors = [KIND_COUNT]enemyBehavior{
+    // Foge da mira ou se esconde, e pula buracos e tiros
+    KIND_GROUND_ENEMY: {
+        patrol: 4, approach: 6, retreat: 5, hopSpeed: 10, sense: 96, keep: 28,
+        retreatFrames: 30, hop: 5 * FIXED_ONE, dodge: 40, cover: true,
+    },
+    // Blindado: avança devagar até o jogador e não foge
+    KIND_HEAVY_ENEMY: {patrol: 3, approach: 5, sense: 96},
+    KIND_FLYING_ENEMY: {patrol: 4, approach: 5, retreat: 6, sense: 80, retreatFrames: 20, dive: 3 * FIXED_ONE},
+    // O escudo cobre a frente: paira adiante do jogador, sem recuar
+    KIND_SHIELDED_ENEMY: {patrol: 4, approach: 4, sense: 80, keep: 40},
+}
+
+// Fração da velocidade da dificuldade
+func aiSpeed(quarters uint8) int16 {
+    return int16(int32(currentEnemySpeed) * int32(quarters) / 4)
+}
+
+// Velocidade para cobrir d pixels neste quadro, limitada a max
+func steer(d int32, max int16) int16 {
+    v := d * FIXED_ONE
+    if v > int32(max) {
+        return max
+    }
+    if v < -int32(max) {
+        return -max
+    }
+    return int16(v)
+}
+
+// Passa o tempo dos estados e, quem está livre, escolhe o próximo
+func updateEnemyState(e *entity) {
+    b := &enemyBehaviors[e.kind]
+    p := leadPlayer()
+    if e.aiRest > 0 {
+        e.aiRest--
+    }
+    if e.aiTimer > 0 {
+        e.aiTimer--
+        if e.aiTimer == 0 {
+            endEnemyState(e)
+        }
+    }
+    if e.aiState == AI_COVER && (p < 0 || !aimedAt(p, e)) {
+        endEnemyState(e)
+    }
+    if e.aiState == AI_PATROL || e.aiState == AI_APPROACH {
+        chooseEnemyState(e, b, p)
+    }
+}
+
+// Fim do recuo, do esconderijo ou do mergulho
+func endEnemyState(e *entity) {
+    e.aiState = AI_PATROL
+    e.aiTimer = 0
+    e.aiRest = AI_REST_FRAMES
+}
+
+func chooseEnemyState(e *entity, b *enemyBehavior, p int) {
+    e.aiState = AI_PATROL
+    if p < 0 || e.age > AI_GIVE_UP_AGE {
+        return
+    }
+    pl := &players[p]
+    dx := e.x + int32(e.width)/2 - (pl.x + PLAYER_WIDTH/2)
+    dy := pl.y + PLAYER_HEIGHT/2 - (e.y + int32(e.height)/2)
+    if dx < 0 || dx > int32(b.sense) {
+        return // Longe, ou o jogador já passou
+    }
+    if e.aiRest == 0 {
+        if b.dive != 0 && dx < AI_DIVE_RANGE && dy > int32(e.height) {
+            e.aiState = AI_DIVE
+            e.aiTimer = AI_DIVE_FRAMES
+            e.velY = b.dive
+            return
+        }
+        if aimedAt(p, e) {
+            if _, ok := findCover(e, pl.x); ok && b.cover {
+                e.aiState = AI_COVER
+                e.aiTimer = AI_COVER_FRAMES
+                return
+            }
+            if b.retreatFrames > 0 {
+                e.aiState = AI_RETREAT
+                e.aiTimer = b.retreatFrames/2 + uint8(aiRand.intn(int32(b.retreatFrames/2)+1))
+                return
+            }
+        }
+    }
+    e.aiState = AI_APPROACH
+}
+
+// A mira do jogador aponta para o inimigo? Compara a direção da mira com a
+// direção até o centro dele (produto escalar e vetorial)
+func aimedAt(p int, e *entity) bool {
+    pl := &players[p]
+    aim := &aimVelocities[pl.aimDirection]
+    dx := e.x + int32(e.width)/2 - (pl.x + PLAYER_WIDTH/2)
+    dy := e.y + int32(e.height)/2 - (pl.y + PLAYER_HEIGHT/2)
+    dot := int32(aim[0])*dx + int32(aim[1])*dy
+    cross := int32(aim[0])*dy - int32(aim[1])*dx
+    if cross < 0 {
+        cross = -cross
+    }
+    return dot > 0 && cross*AI_AIM_CONE <= dot
+}
+
+// x logo atrás (à direita) do obstáculo mais próximo entre o jogador em px
+// e o inimigo
+func findCover(e *entity, px int32) (int32, bool) {
+    best, found := int32(0), false
+    for i := 0; i < MAX_ENTITIES; i++ {
+        h := &entities[i]
+        if !h.active || entityKinds[h.kind].team != TEAM_HAZARD || h.x <= px || h.x >= e.x {
+            continue
+        }
+        x := h.x + int32(h.width) + 1
+        if x-e.x <= AI_COVER_RANGE && e.x-x <= AI_COVER_RANGE && (!found || x > best) {
+            best, found = x, true
+        }
+    }
+    return best, found
+}
+
+// Parado atrás do obstáculo: tiros retos ou subindo vindos da esquerda
+// acertam o obstáculo
+func coverBlocks(e, shot *entity) bool {
+    return e.aiState == AI_COVER && e.velX == 0 && e.velY == 0 && shot.velX > 0 && shot.velY <= 0
+}
+
+// Terrestres: anda conforme o estado, pula e acompanha o chão
+func moveWalker(e *entity) {
+    b := &enemyBehaviors[e.kind]
+    p := leadPlayer()
+    switch e.aiState {
+    case AI_PATROL:
+        e.velX = -aiSpeed(b.patrol)
+    case AI_APPROACH:
+        gap := e.x - (players[p].x + PLAYER_WIDTH)
+        e.velX = -steer(gap-int32(b.keep), aiSpeed(b.approach))
+        if e.velX > 0 {
+            e.velX = 0 // Perto demais: espera, sem voltar
+        }
+    case AI_RETREAT:
+        e.velX = aiSpeed(b.retreat)
+    case AI_COVER:
+        if x, ok := findCover(e, players[p].x); ok {
+            e.velX = steer(x-e.x, aiSpeed(b.approach))
+        } else {
+            endEnemyState(e)
+            e.velX = -aiSpeed(b.patrol)
+        }
+    }
+
+    // No alto do pulo velY também passa por 0: só está no chão quem não pula
+    ground, ok := surfaceY(e.x, int32(e.width))
+    feet := e.y + int32(e.height)
+    grounded := e.aiState != AI_HOP && e.velY == 0
+    if ok && grounded && b.hop != 0 && e.aiState != AI_COVER && (pitAhead(e) || dodgeShot(e, b)) {
+        e.aiState = AI_HOP
+        e.velY = -b.hop
+        if e.velX < 0 {
+            e.velX = -aiSpeed(b.hopSpeed)
+        } else if e.velX > 0 {
+            e.velX = aiSpeed(b.hopSpeed)
+        }
+    }
+
+    // Anda sobre o terreno; no buraco, cai. No pulo, pousa no chão que os
+    // pés vão cruzar
+    switch {
+    case ok && grounded:
+        e.y = ground - int32(e.height)
+    case ok && e.aiState == AI_HOP && e.velY >= 0 && feet <= ground+AI_LAND_REACH && feet+int32(e.velY>>FIXED_SHIFT) >= ground:
+        e.y = ground - int32(e.height)
+        e.subY = 0
+        e.velY = 0
+        e.aiState = AI_PATROL
+    default:
+        e.velY += PLAYER_GRAVITY
+    }
+}
+
+// A próxima coluna no sentido em que anda é buraco?
+func pitAhead(e *entity) bool {
+    x := e.x - 1
+    if e.velX > 0 {
+        x = e.x + int32(e.width)
+    } else if e.velX == 0 {
+        return false
+    }
+    _, ok := groundY(x)
+    return !ok
+}
+
+// Um tiro do jogador acabou de chegar a AI_DODGE_RANGE na altura do
+// inimigo; pula com a chance dodge
+func dodgeShot(e *entity, b *enemyBehavior) bool {
+    if b.dodge == 0 {
+        return false
+    }
+    for i := 0; i < MAX_ENTITIES; i++ {
+        s := &entities[i]
+        if !s.active || s.kind != KIND_BULLET || s.velX <= 0 {
+            continue
+        }
+        gap := e.x - (s.x + int32(s.width))
+        if gap > AI_DODGE_RANGE-AI_DODGE_BAND && gap <= AI_DODGE_RANGE &&
+           s.y+int32(s.height) > e.y && s.y < e.y+int32(e.height) {
+            return aiRand.intn(100) < int32(b.dodge)
+        }
+    }
+    return false
+}
+
+// Voadores: ondulam patrulhando, pairam acima do jogador e mergulham
+func moveFlyer(e *entity) {
+    b := &enemyBehaviors[e.kind]
+    p := leadPlayer()
+    switch e.aiState {
+    case AI_APPROACH:
+        pl := &players[p]
+        y := pl.y - AI_HOVER
+        if y < AI_FLY_TOP {
+            y = AI_FLY_TOP
+        } else if y > AI_FLY_BOTTOM {
+            y = AI_FLY_BOTTOM
+        }
+        e.velX = steer(pl.x+int32(b.keep)-e.x, aiSpeed(b.approach))
+        e.velY = steer(y-e.y, FIXED_ONE)
+        return
+    case AI_RETREAT:
+        e.velX = aiSpeed(b.retreat)
+        e.velY = 0
+        return
+    case AI_DIVE:
+        // Desce até a altura dos pés do jogador (ou perto do chão) e sobe
+        e.velX = -aiSpeed(b.patrol)
+        if e.velY > 0 {
+            bottom := AI_FLY_BOTTOM + int32(AI_HOVER)
+            if p >= 0 {
+                bottom = players[p].y + PLAYER_HEIGHT - int32(e.height)
+            }
+            if ground, ok := groundY(e.x + int32(e.width)/2); ok && ground-int32(e.height)-2 < bottom {
+                bottom = ground - int32(e.height) - 2
+            }
+            if e.y >= bottom {
+                e.velY = -b.dive / 2
+            }
+        } else if e.y <= AI_FLY_TOP {
+            endEnemyState(e)
+        }
+        return
+    }
+
+    e.velX = -aiSpeed(b.patrol)
+    // Movimento senoidal para voar
+    if (e.age/15)%2 == 0 {
+        e.velY = -FIXED_ONE
+    } else {
+        e.velY = FIXED_ONE
+    }
+    if e.y < AI_FLY_TOP {
+        e.y = AI_FLY_TOP
+        e.velY = FIXED_ONE
+    }
+    if e.y > AI_FLY_BOTTOM {
+        e.y = AI_FLY_BOTTOM
+        e.velY = -FIXED_ONE
+    }
+}
@@ -0,0 +1,269 @@
+package main
+
+// Chefes. Ao chegar em nextBossScore (em pontos de progresso; ver
+// runProgress) a câmera trava, proceduralSpawn() para e o chefe entra pela
+// direita. Ele tem três partes no pool de entidades: o núcleo e duas
+// torretas. Fases:
+//
+//   1. Torretas vivas: elas disparam leques de tiros; o núcleo é blindado.
+//   2. Só o núcleo: ele esmaga o chão (a onda de choque exige um pulo) e
+//      atira na direção do jogador da frente.
+//   3. Núcleo com metade da vida: também invoca inimigos voadores.
+//
+// Os ataques seguem bossTimer e só o tipo dos reforços sai de aiRand (um
+// stream semeado pela partida), então a luta é igual em todo replay.
+const (
+    BOSS_FIRST_SCORE = 200
+    BOSS_INTERVAL = 400 // Pontos entre o fim de um chefe e o próximo
+    BOSS_BONUS = 250    // Para quem destruir o núcleo
+
+    // Estados
+    BOSS_NONE = 0
+    BOSS_ENTERING = 1
+    BOSS_FIGHTING = 2
+
+    // Partes
+    BOSS_CORE = 0
+    BOSS_TOP_TURRET = 1
+    BOSS_BOTTOM_TURRET = 2
+    BOSS_PARTS = 3
+
+    // Arena (coordenadas de tela)
+    BOSS_X = 128
+    BOSS_Y = 44
+    BOSS_PLAYER_MAX_X = 56 // Os jogadores param aqui
+
+    // Ataques (quadros)
+    BOSS_VOLLEY_RATE = 60
+    BOSS_AIMED_RATE = 45
+    BOSS_SLAM_RATE = 150
+    BOSS_SUMMON_RATE = 200
+    BOSS_SLAM_SPEED = 4
+    BOSS_SHOT_SPEED = 2 * FIXED_ONE
+    SHOCKWAVE_WIDTH = 6
+    SHOCKWAVE_HEIGHT = 4
+
+    // Esmagada
+    SLAM_NONE = 0
+    SLAM_DROP = 1
+    SLAM_RISE = 2
+)
+
+var bossPartKinds = [BOSS_PARTS]int8{KIND_BOSS_CORE, KIND_BOSS_TURRET, KIND_BOSS_TURRET}
+
+// Posição de cada parte relativa ao núcleo
+var bossPartOffsets = [BOSS_PARTS][2]int32{
+    BOSS_CORE: {0, 0},
+    BOSS_TOP_TURRET: {-8, 0},
+    BOSS_BOTTOM_TURRET: {-8, 8},
+}
+
+var (
+    bossState int8 = BOSS_NONE
+    bossLevel int32 = 0 // Chefes derrotados nesta partida
+    nextBossScore int32 = BOSS_FIRST_SCORE
+    bossX, bossY int32 // Núcleo, em coordenadas do mundo
+    bossTimer int32 = 0
+    bossSlam int8 = SLAM_NONE
+    bossParts [BOSS_PARTS]int // Slots no pool
+    bossMaxHP int32 = 0
+)
+
+func resetBoss() {
+    bossState = BOSS_NONE
+    bossLevel = 0
+    nextBossScore = BOSS_FIRST_SCORE
+}
+
+func bossActive() bool {
+    return bossState != BOSS_NONE
+}
+
+func bossPartAlive(part int) bool {
+    slot := bossParts[part]
+    return slot >= 0 && entities[slot].active && entities[slot].kind == bossPartKinds[part]
+}
+
+// Vida somada das partes vivas
+func bossHP() int32 {
+    hp := int32(0)
+    for i := 0; i < BOSS_PARTS; i++ {
+        if bossPartAlive(i) {
+            hp += int32(entities[bossParts[i]].hp)
+        }
+    }
+    return hp
+}
+
+func updateBoss() {
+    switch bossState {
+    case BOSS_NONE:
+        if runProgress() >= nextBossScore {
+            startBoss()
+        }
+        return
+    case BOSS_ENTERING:
+        bossX--
+        if bossX <= cameraX+BOSS_X {
+            bossX = cameraX + BOSS_X
+            bossState = BOSS_FIGHTING
+            bossTimer = 0
+            for i := BOSS_TOP_TURRET; i < BOSS_PARTS; i++ {
+                entities[bossParts[i]].armored = false
+            }
+        }
+    case BOSS_FIGHTING:
+        if !bossPartAlive(BOSS_CORE) {
+            defeatBoss()
+            return
+        }
+        bossTimer++
+        updateBossAttacks()
+    }
+    placeBossParts()
+}
+
+func startBoss() {
+    bossState = BOSS_ENTERING
+    bossX = cameraX + SCREEN_WIDTH + 16
+    bossY = BOSS_Y
+    bossSlam = SLAM_NONE
+    bossMaxHP = 0
+
+    // A arena é plana; limpa os obstáculos que ficariam dentro dela
+    levelTerrain(cameraX)
+    for i := 0; i < MAX_ENTITIES; i++ {
+        e := &entities[i]
+        if e.active && entityKinds[e.kind].team == TEAM_HAZARD && e.x > cameraX+BOSS_PLAYER_MAX_X {
+            e.active = false
+        }
+    }
+
+    for i := 0; i < BOSS_PARTS; i++ {
+        slot := spawnEntity(bossPartKinds[i], bossX, bossY, 0, 0)
+        bossParts[i] = slot
+        if slot < 0 {
+            continue
+        }
+        e := &entities[slot]
+        e.hp += int8(bossLevel * 2) // Cada chefe é mais resistente que o anterior
+        e.armored = true            // Até terminar de entrar
+        bossMaxHP += int32(e.hp)
+    }
+    placeBossParts()
+}
+
+func placeBossParts() {
+    for i := 0; i < BOSS_PARTS; i++ {
+        if bossPartAlive(i) {
+            e := &entities[bossParts[i]]
+            e.x = bossX + bossPartOffsets[i][0]
+            e.y = bossY + bossPartOffsets[i][1]
+        }
+    }
+}
+
+func updateBossAttacks() {
+    turrets := 0
+    for i := BOSS_TOP_TURRET; i < BOSS_PARTS; i++ {
+        if bossPartAlive(i) {
+            turrets++
+        }
+    }
+    core := &entities[bossParts[BOSS_CORE]]
+    core.armored = turrets > 0
+
+    // Fase 1: as torretas se revezam nos leques
+    if turrets > 0 {
+        if bossTimer%BOSS_VOLLEY_RATE == 0 {
+            part := BOSS_TOP_TURRET + int(bossTimer/BOSS_VOLLEY_RATE)%2
+            if !bossPartAlive(part) {
+                part = BOSS_TOP_TURRET + BOSS_BOTTOM_TURRET - part
+            }
+            turret := &entities[bossParts[part]]
+            for velY := int16(0); velY <= 2*FIXED_ONE; velY += FIXED_ONE {
+                spawnEntity(KIND_ENEMY_BULLET, turret.x-2, turret.y+2, -2*FIXED_ONE, velY)
+            }
+        }
+        return
+    }
+
+    // Fases 2 e 3
+    if bossSlam == SLAM_NONE && bossTimer%BOSS_SLAM_RATE == 0 {
+        bossSlam = SLAM_DROP
+    }
+    updateBossSlam()
+
+    if bossSlam == SLAM_NONE && bossTimer%BOSS_AIMED_RATE == 0 {
+        fireAimedShot(core)
+    }
+    enraged := int32(core.hp)*2 <= int32(entityKinds[KIND_BOSS_CORE].hp)+bossLevel*2
+    if enraged && bossTimer%BOSS_SUMMON_RATE == 0 {
+        spawnEnemy(bossX-12, bossY, flyingEnemyKind(aiRand.intn(100)))
+    }
+}
+
+func updateBossSlam() {
+    switch bossSlam {
+    case SLAM_DROP:
+        bossY += BOSS_SLAM_SPEED
+        if bottom := GROUND_Y - int32(entityKinds[KIND_BOSS_CORE].height); bossY >= bottom {
+            bossY = bottom
+            bossSlam = SLAM_RISE
+            spawnEntity(KIND_SHOCKWAVE, bossX-SHOCKWAVE_WIDTH, GROUND_Y-SHOCKWAVE_HEIGHT, -3*FIXED_ONE, 0)
+            playSfx(SFX_BOSS_SLAM)
+        }
+    case SLAM_RISE:
+        bossY--
+        if bossY <= BOSS_Y {
+            bossY = BOSS_Y
+            bossSlam = SLAM_NONE
+        }
+    }
+}
+
+// Tiro na direção do jogador mais à frente
+func fireAimedShot(core *entity) {
+    lead := leadPlayer()
+    if lead < 0 {
+        return
+    }
+    x, y := core.x-2, core.y+int32(core.height)/2
+    velX, velY := aimVector(players[lead].x+PLAYER_WIDTH/2-x, players[lead].y+PLAYER_HEIGHT/2-y, BOSS_SHOT_SPEED)
+    spawnEntity(KIND_ENEMY_BULLET, x, y, velX, velY)
+}
+
+func defeatBoss() {
+    for i := 0; i < BOSS_PARTS; i++ {
+        if bossPartAlive(i) {
+            e := &entities[bossParts[i]]
+            e.active = false
+            createExplosion(e.x, e.y)
+        }
+    }
+    createExplosion(bossX, bossY)
+    createExplosion(bossX+8, bossY+8)
+    playSfx(SFX_BOSS_SLAM)
+
+    bossLevel++
+    bossState = BOSS_NONE
+    // No desafio os chefes ficam em distâncias fixas, iguais para todos
+    if challengeRun {
+        nextBossScore += BOSS_INTERVAL
+    } else {
+        nextBossScore = score + BOSS_INTERVAL
+    }
+}
+
+// Barra de vida na faixa do chão
+func drawBossBar() {
+    if !bossActive() || bossMaxHP == 0 {
+        return
+    }
+    setColors(0x04)
+    drawSimpleText("BOSS", 10, 144)
+    setColors(0x01)
+    rect(40, 145, 110, 5)
+    setColors(0x03)
+    rect(40, 145, 110*bossHP()/bossMaxHP, 5)
+}
@@ -0,0 +1,504 @@
+package main
+
+// Entidades: tiros, inimigos, obstáculos e partículas dividem um único pool.
+//
+// O comportamento de cada tipo vem da tabela entityKinds: os componentes
+// ligados em flags dizem quais sistemas genéricos (movimento, tempo de vida,
+// culling, colisão e desenho) atuam sobre ele. Um tipo novo de inimigo ou de
+// projétil é uma linha na tabela; só a IA fica em updateEntityAI() e ai.go.
+//
+// Dano: cada acerto tira damage pontos de hp do alvo. Um alvo que sobrevive
+// pisca por HIT_FLASH_FRAMES; um com COMP_SHIELD ignora tiros que chegam
+// pela frente (vindos da esquerda), assim como um inimigo escondido atrás
+// de uma pedra (ai.go). Uma entidade com armored ligado (as partes do
+// chefe, em boss.go) não perde vida com acerto nenhum.
+//
+// Um tipo com COMP_PICKUP não fere: o jogador que encostar nele o recolhe.
+const (
+    MAX_ENTITIES = MAX_PLAYER_BULLETS + MAX_ENEMY_BULLETS + MAX_ENEMIES + MAX_OBSTACLES + MAX_PARTICLES + BOSS_PARTS + MAX_PICKUPS
+
+    // Limites simultâneos por time
+    MAX_ENEMY_BULLETS = 6
+    MAX_ENEMIES = 3
+    MAX_OBSTACLES = 3
+    MAX_PARTICLES = 4
+
+    // Componentes
+    COMP_POSITION = 1 << 0
+    COMP_VELOCITY = 1 << 1
+    COMP_HITBOX = 1 << 2
+    COMP_SPRITE = 1 << 3   // Desenhado do atlas; sem ele, drawEntityShape()
+    COMP_LIFETIME = 1 << 4 // Some depois de life quadros
+    COMP_DAMAGE = 1 << 5   // Fere o que estiver em hits
+    COMP_TEAM = 1 << 6
+    COMP_PROJECTILE = 1 << 7 // Consumido ao acertar; sai da tela em qualquer direção
+    COMP_SHIELD = 1 << 8
+    COMP_PICKUP = 1 << 9 // Recolhido pelos jogadores (powerup.go)
+
+    HIT_FLASH_FRAMES = 8
+
+    // Times. Os jogadores não são entidades, mas têm time para que os
+    // inimigos os incluam em hits
+    TEAM_PLAYER = 0
+    TEAM_PLAYER_SHOT = 1
+    TEAM_ENEMY = 2
+    TEAM_ENEMY_SHOT = 3
+    TEAM_HAZARD = 4
+    TEAM_FX = 5
+    TEAM_BOSS = 6
+    TEAM_PICKUP = 7
+    TEAM_COUNT = 8
+
+    // Tipos, na ordem de desenho
+    KIND_BOSS_CORE = 0
+    KIND_BOSS_TURRET = 1
+    KIND_SHOCKWAVE = 2
+    KIND_BULLET = 3
+    KIND_ENEMY_BULLET = 4
+    KIND_GROUND_ENEMY = 5
+    KIND_FLYING_ENEMY = 6
+    KIND_HEAVY_ENEMY = 7
+    KIND_SHIELDED_ENEMY = 8
+    KIND_ROCK = 9
+    KIND_SPIKE = 10
+    KIND_PICKUP = 11
+    KIND_PARTICLE = 12
+    KIND_COUNT = 13
+)
+
+// Quantas entidades de cada time podem existir ao mesmo tempo
+var teamLimits = [TEAM_COUNT]int8{
+    TEAM_PLAYER: 0,
+    TEAM_PLAYER_SHOT: MAX_PLAYER_BULLETS,
+    TEAM_ENEMY: MAX_ENEMIES,
+    TEAM_ENEMY_SHOT: MAX_ENEMY_BULLETS,
+    TEAM_HAZARD: MAX_OBSTACLES,
+    TEAM_FX: MAX_PARTICLES,
+    TEAM_BOSS: BOSS_PARTS,
+    TEAM_PICKUP: MAX_PICKUPS,
+}
+
+type entityKind struct {
+    flags uint16
+    team int8
+    hits uint8 // Máscara dos times atingidos (1 << TEAM_*)
+    width, height int8
+    hitbox [4]int8 // Ajuste da caixa de colisão: dx, dy, dw, dh
+    hp int8
+    damage int8
+    life int16
+    gravity int16 // Ponto fixo 8.8
+    cull int16 // Margem fora da tela antes de sumir
+    sprite int8
+    colors uint16
+    points int32 // Para quem destruir
+    deathSfx int8
+}
+
+var entityKinds = [KIND_COUNT]entityKind{
+    // Partes do chefe: a posição vem de boss.go, não da velocidade
+    KIND_BOSS_CORE: {
+        flags: COMP_POSITION | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_BOSS, hits: 1 << TEAM_PLAYER,
+        width: SPRITE_BOSS_CORE_WIDTH, height: SPRITE_BOSS_CORE_HEIGHT, hitbox: [4]int8{1, 1, -2, -2},
+        hp: 12, damage: 1, cull: 50, sprite: SPRITE_BOSS_CORE, colors: 0x243,
+        points: BOSS_BONUS, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    KIND_BOSS_TURRET: {
+        flags: COMP_POSITION | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_BOSS, hits: 1 << TEAM_PLAYER,
+        width: SPRITE_BOSS_TURRET_WIDTH, height: SPRITE_BOSS_TURRET_HEIGHT,
+        hp: 4, damage: 1, cull: 50, sprite: SPRITE_BOSS_TURRET, colors: 0x34,
+        points: 50, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    // Onda da esmagada: corre pelo chão e não pode ser abatida, só pulada
+    KIND_SHOCKWAVE: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_DAMAGE | COMP_TEAM | COMP_PROJECTILE,
+        team: TEAM_ENEMY_SHOT, hits: 1 << TEAM_PLAYER,
+        width: SHOCKWAVE_WIDTH, height: SHOCKWAVE_HEIGHT, hp: 1, damage: 1, cull: 20, deathSfx: SFX_NONE,
+    },
+    KIND_BULLET: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_DAMAGE | COMP_TEAM | COMP_PROJECTILE,
+        team: TEAM_PLAYER_SHOT, hits: 1<<TEAM_ENEMY | 1<<TEAM_ENEMY_SHOT | 1<<TEAM_BOSS,
+        width: BULLET_WIDTH, height: BULLET_HEIGHT, hp: 1, damage: 1, cull: 20, deathSfx: SFX_NONE,
+    },
+    KIND_ENEMY_BULLET: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_DAMAGE | COMP_TEAM | COMP_PROJECTILE,
+        team: TEAM_ENEMY_SHOT, hits: 1 << TEAM_PLAYER,
+        width: ENEMY_BULLET_WIDTH, height: ENEMY_BULLET_HEIGHT, hp: 1, damage: 1, cull: 20,
+        points: 5, deathSfx: SFX_BULLET_INTERCEPT,
+    },
+    KIND_GROUND_ENEMY: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_ENEMY, hits: 1 << TEAM_PLAYER,
+        width: 8, height: 12, hp: 1, damage: 1, cull: 50, sprite: SPRITE_GROUND_ENEMY, colors: 0x03,
+        points: 10, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    KIND_FLYING_ENEMY: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_ENEMY, hits: 1 << TEAM_PLAYER,
+        width: 8, height: 8, hp: 1, damage: 1, cull: 50, sprite: SPRITE_FLYING_ENEMY, colors: 0x03,
+        points: 10, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    // Terrestre blindado: aguenta três tiros e tira dois corações no contato
+    KIND_HEAVY_ENEMY: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_ENEMY, hits: 1 << TEAM_PLAYER,
+        width: 8, height: 12, hp: 3, damage: 2, cull: 50, sprite: SPRITE_GROUND_ENEMY, colors: 0x04,
+        points: 30, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    // Voador com escudo: só cai com tiros de baixo
+    KIND_SHIELDED_ENEMY: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM | COMP_SHIELD,
+        team: TEAM_ENEMY, hits: 1 << TEAM_PLAYER,
+        width: 8, height: 8, hp: 2, damage: 1, cull: 50, sprite: SPRITE_FLYING_ENEMY, colors: 0x03,
+        points: 20, deathSfx: SFX_ENEMY_EXPLODE,
+    },
+    // Obstáculos perdoam um pixel de cada lado e a base; o espeto mata na hora
+    KIND_ROCK: {
+        flags: COMP_POSITION | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_HAZARD, hits: 1 << TEAM_PLAYER,
+        width: 8, height: 8, hitbox: [4]int8{1, 0, -2, -2}, hp: 1, damage: 1, cull: 50,
+        sprite: SPRITE_ROCK, colors: 0x04, deathSfx: SFX_NONE,
+    },
+    KIND_SPIKE: {
+        flags: COMP_POSITION | COMP_HITBOX | COMP_SPRITE | COMP_DAMAGE | COMP_TEAM,
+        team: TEAM_HAZARD, hits: 1 << TEAM_PLAYER,
+        width: 6, height: 8, hitbox: [4]int8{1, 0, -2, -2}, hp: 1, damage: PLAYER_MAX_HP, cull: 50,
+        sprite: SPRITE_SPIKE, colors: 0x04, deathSfx: SFX_NONE,
+    },
+    // Cápsula de power-up; variant diz qual
+    KIND_PICKUP: {
+        flags: COMP_POSITION | COMP_TEAM | COMP_PICKUP,
+        team: TEAM_PICKUP, width: SPRITE_PICKUP_WIDTH, height: SPRITE_PICKUP_HEIGHT, cull: 50, deathSfx: SFX_NONE,
+    },
+    KIND_PARTICLE: {
+        flags: COMP_POSITION | COMP_VELOCITY | COMP_LIFETIME,
+        team: TEAM_FX, width: 2, height: 2, life: 25, gravity: FIXED_ONE, cull: 50, deathSfx: SFX_NONE,
+    },
+}
+
+type entity struct {
+    x, y int32
+    subX, subY uint8 // Fração da posição (fixed.go)
+    velX, velY int16 // Ponto fixo 8.8
+    width, height int8
+    kind int8
+    variant int8 // Subtipo; o power-up de uma KIND_PICKUP
+    owner int8 // Jogador que disparou, ou -1
+    age uint16 // Quadros desde o spawn
+    life int16
+    hp int8
+    flash uint8 // Quadros restantes do flash de dano
+    aiState int8 // AI_* (ai.go)
+    aiTimer uint8 // Quadros restantes do estado; 0 = sem prazo
+    aiRest uint8  // Quadros até poder recuar, se esconder ou mergulhar de novo
+    armored bool
+    active bool
+}
+
+var entities [MAX_ENTITIES]entity
+
+func clearEntities() {
+    for i := 0; i < MAX_ENTITIES; i++ {
+        entities[i].active = false
+    }
+}
+
+// Quantas entidades ativas pertencem ao time
+func countTeam(team int8) int8 {
+    n := int8(0)
+    for i := 0; i < MAX_ENTITIES; i++ {
+        if entities[i].active && entityKinds[entities[i].kind].team == team {
+            n++
+        }
+    }
+    return n
+}
+
+// Ocupa o primeiro slot livre; devolve -1 se o time já está no limite.
+// A velocidade é em ponto fixo 8.8
+func spawnEntity(kind int8, x, y int32, velX, velY int16) int {
+    k := &entityKinds[kind]
+    if countTeam(k.team) >= teamLimits[k.team] {
+        return -1
+    }
+    for i := 0; i < MAX_ENTITIES; i++ {
+        e := &entities[i]
+        if !e.active {
+            *e = entity{
+                x: x, y: y,
+                velX: velX, velY: velY,
+                width: k.width, height: k.height,
+                kind: kind,
+                owner: -1,
+                life: k.life,
+                hp: k.hp,
+                active: true,
+            }
+            return i
+        }
+    }
+    return -1
+}
+
+// Roda todos os sistemas, na ordem em que o jogo original os rodava
+func updateEntities() {
+    for i := 0; i < MAX_ENTITIES; i++ {
+        e := &entities[i]
+        if !e.active {
+            continue
+        }
+        k := &entityKinds[e.kind]
+        e.age++
+        if e.flash > 0 {
+            e.flash--
+        }
+        updateEntityAI(e)
+
+        if k.flags&COMP_VELOCITY != 0 {
+            integrate(&e.x, &e.subX, e.velX)
+            integrate(&e.y, &e.subY, e.velY)
+            e.velY += k.gravity
+        }
+        if k.flags&COMP_LIFETIME != 0 {
+            e.life--
+            if e.life <= 0 {
+                e.active = false
+                continue
+            }
+        }
+        cullEntity(e, k)
+    }
+}
+
+// Remove o que ficou para trás da câmera ou caiu num buraco; projéteis
+// também somem ao sair da tela pela frente, por cima ou por baixo
+func cullEntity(e *entity, k *entityKind) {
+    margin := int32(k.cull)
+    if e.x < cameraX-margin || e.y > SCREEN_HEIGHT+margin {
+        e.active = false
+    }
+    if k.flags&COMP_PROJECTILE != 0 &&
+       (e.x > cameraX+SCREEN_WIDTH+margin || e.y < -margin || e.y > SCREEN_HEIGHT+margin) {
+        e.active = false
+    }
+}
+
+// Comportamento próprio de cada tipo; o movimento dos inimigos vem de ai.go
+func updateEntityAI(e *entity) {
+    switch e.kind {
+    case KIND_GROUND_ENEMY, KIND_HEAVY_ENEMY:
+        if e.age%ENEMY_SHOOT_RATE == 0 {
+            spawnEntity(KIND_ENEMY_BULLET, e.x-2, e.y+6, -2*FIXED_ONE, 0)
+        }
+        updateEnemyState(e)
+        moveWalker(e)
+    case KIND_FLYING_ENEMY, KIND_SHIELDED_ENEMY:
+        if e.age%ENEMY_SHOOT_RATE == 0 {
+            spawnEntity(KIND_ENEMY_BULLET, e.x+4, e.y+8, 0, FIXED_ONE)
+        }
+        updateEnemyState(e)
+        moveFlyer(e)
+    }
+}
+
+// Caixa de colisão já com o ajuste do tipo
+func entityHitbox(e *entity) (int32, int32, int32, int32) {
+    adj := &entityKinds[e.kind].hitbox
+    return e.x + int32(adj[0]), e.y + int32(adj[1]), int32(e.width + adj[2]), int32(e.height + adj[3])
+}
+
+// Colisões entre entidades e depois contra os jogadores, para que um tiro
+// interceptado no quadro não chegue a acertar ninguém
+func checkCollisions() {
+    for i := 0; i < MAX_ENTITIES; i++ {
+        for j := 0; j < MAX_ENTITIES && entities[i].active; j++ {
+            if i != j && entities[j].active && entityHits(&entities[i], &entities[j]) {
+                resolveHit(&entities[i], &entities[j])
+            }
+        }
+    }
+
+    for i := 0; i < MAX_ENTITIES; i++ {
+        e := &entities[i]
+        k := &entityKinds[e.kind]
+        pickup := k.flags&COMP_PICKUP != 0
+        if !e.active || !pickup && (k.flags&COMP_DAMAGE == 0 || k.hits&(1<<TEAM_PLAYER) == 0) {
+            continue
+        }
+        x, y, w, h := entityHitbox(e)
+        for p := 0; p < MAX_PLAYERS && e.active; p++ {
+            // Jogador invencível: nem os tiros são consumidos
+            if (players[p].flags & 0x02) == 0 || !pickup && players[p].invuln > 0 {
+                continue
+            }
+            if !collision(players[p].x, players[p].y, PLAYER_WIDTH, PLAYER_HEIGHT, x, y, w, h) {
+                continue
+            }
+            if pickup {
+                collectPickup(p, e)
+            } else {
+                hurtPlayer(p, k.damage)
+                if k.flags&COMP_PROJECTILE != 0 {
+                    e.active = false
+                }
+            }
+        }
+    }
+}
+
+// a atinge b? Dois projéteis se interceptam com um pixel de folga
+func entityHits(a, b *entity) bool {
+    ka, kb := &entityKinds[a.kind], &entityKinds[b.kind]
+    if ka.flags&COMP_DAMAGE == 0 || kb.flags&COMP_HITBOX == 0 || ka.hits&(1<<uint(kb.team)) == 0 {
+        return false
+    }
+    ax, ay, aw, ah := entityHitbox(a)
+    bx, by, bw, bh := entityHitbox(b)
+    if ka.flags&kb.flags&COMP_PROJECTILE != 0 {
+        return collision(ax-1, ay-1, aw+2, ah+2, bx-1, by-1, bw+2, bh+2)
+    }
+    return collision(ax, ay, aw, ah, bx, by, bw, bh)
+}
+
+func resolveHit(a, b *entity) {
+    ka, kb := &entityKinds[a.kind], &entityKinds[b.kind]
+    if ka.flags&COMP_PROJECTILE != 0 {
+        a.active = false
+    }
+    if b.armored || kb.flags&COMP_SHIELD != 0 && a.velX > 0 || coverBlocks(b, a) {
+        playSfx(SFX_SHIELD_BLOCK)
+        return
+    }
+    b.hp -= ka.damage
+    if b.hp > 0 {
+        b.flash = HIT_FLASH_FRAMES
+        playSfx(SFX_ENEMY_HIT)
+        return
+    }
+
+    b.active = false
+    if a.owner >= 0 {
+        addScore(int(a.owner), kb.points)
+    }
+    if kb.team == TEAM_ENEMY || kb.team == TEAM_BOSS {
+        runKills++
+    }
+    createExplosion(b.x, b.y)
+    if kb.deathSfx != SFX_NONE {
+        playSfx(int(kb.deathSfx))
+    }
+}
+
+// Cada tipo num passe, na ordem dos KIND_*
+func drawEntities() {
+    for kind := int8(0); kind < KIND_COUNT; kind++ {
+        k := &entityKinds[kind]
+        for i := 0; i < MAX_ENTITIES; i++ {
+            e := &entities[i]
+            if !e.active || e.kind != kind {
+                continue
+            }
+            screenX := e.x - cameraX
+            if screenX+int32(e.width) <= 0 || screenX >= SCREEN_WIDTH {
+                continue
+            }
+            if k.flags&COMP_SPRITE != 0 {
+                colors := k.colors
+                if e.flash > 0 && (e.flash/2)%2 == 1 {
+                    colors = flashColors(colors)
+                }
+                drawSprite(int(k.sprite), 0, screenX, e.y, colors, 0)
+                if k.flags&COMP_SHIELD != 0 {
+                    drawSprite(SPRITE_SHIELD, 0, screenX-SPRITE_SHIELD_WIDTH-1, e.y-1, 0x34, 0)
+                }
+            } else {
+                drawEntityShape(e, screenX)
+            }
+        }
+    }
+}
+
+// Cor de destaque do flash de dano: toda cor desenhada vira a 4, ou a 3 em
+// quem já é da cor 4
+func flashColors(colors uint16) uint16 {
+    flash := uint16(0x04)
+    if colors == 0x04 {
+        flash = 0x03
+    }
+    out := uint16(0)
+    for shift := uint(0); shift < 16; shift += 4 {
+        if (colors>>shift)&0x0F != 0 {
+            out |= flash << shift
+        }
+    }
+    return out
+}
+
+// Corpo na cor 4 e ponta na cor 3, do lado para onde o tiro vai
+func drawBullet(e *entity, screenX int32) {
+    w, h := int32(e.width), int32(e.height)
+    setColors(0x04)
+    switch {
+    case w > h:
+        // Bala horizontal
+        rect(screenX, e.y, w, h)
+        tipX := screenX + w - 2
+        if e.velX < 0 {
+            tipX = screenX
+        }
+        setColors(0x03)
+        rect(tipX, e.y, 2, h)
+    case h > w:
+        // Bala vertical
+        rect(screenX, e.y, w, h)
+        tipY := e.y
+        if e.velY > 0 {
+            tipY = e.y + h - 2
+        }
+        setColors(0x03)
+        rect(screenX, tipY, w, 2)
+    default:
+        // Diagonal: um pixel por linha, terminando na ponta
+        for i := int32(0); i < w; i++ {
+            px, py := i, i
+            if e.velX < 0 {
+                px = w - 1 - i
+            }
+            if e.velY < 0 {
+                py = h - 1 - i
+            }
+            if i == w-1 {
+                setColors(0x03)
+            }
+            rect(screenX+px, e.y+py, 1, 1)
+        }
+    }
+}
+
+// Tipos sem sprite
+func drawEntityShape(e *entity, screenX int32) {
+    switch e.kind {
+    case KIND_BULLET:
+        drawBullet(e, screenX)
+    case KIND_ENEMY_BULLET:
+        setColors(0x03)
+        rect(screenX, e.y, ENEMY_BULLET_WIDTH, ENEMY_BULLET_HEIGHT)
+        // Pixel central mais brilhante para melhor visibilidade
+        setColors(0x04)
+        rect(screenX+1, e.y+1, 1, 1)
+    case KIND_PICKUP:
+        drawPickup(e, screenX)
+    case KIND_SHOCKWAVE:
+        setColors(0x03)
+        rect(screenX, e.y+1, SHOCKWAVE_WIDTH, SHOCKWAVE_HEIGHT-1)
+        setColors(0x04)
+        rect(screenX+1, e.y, SHOCKWAVE_WIDTH-2, 1)
+    case KIND_PARTICLE:
+        setColors(0x32)
+        rect(screenX, e.y, 2, 2)
+        setColors(0x03)
+        rect(screenX, e.y, 1, 1)
+    }
+}
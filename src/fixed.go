@@ -0,0 +1,23 @@
+package main
+
+// Ponto fixo 8.8 para a física. As velocidades (e a gravidade) são int16 em
+// 1/256 de pixel por quadro; as posições continuam em pixels inteiros, que é
+// o que colisão e desenho usam, e a fração fica guardada ao lado (subX,
+// subY). Assim 1.5 px/quadro anda 1, 2, 1, 2... sem perder nada.
+const (
+    FIXED_SHIFT = 8
+    FIXED_ONE = 1 << FIXED_SHIFT // 1 pixel
+    FIXED_MASK = FIXED_ONE - 1
+)
+
+// Soma uma velocidade 8.8 à posição, guardando a fração para o próximo quadro
+func integrate(pos *int32, frac *uint8, vel int16) {
+    sum := int32(*frac) + int32(vel)
+    *pos += sum >> FIXED_SHIFT // Deslocamento aritmético: arredonda para baixo
+    *frac = uint8(sum & FIXED_MASK)
+}
+
+// Interpola de from até to conforme progress vai de 0 a total
+func ramp(from, to, progress, total int32) int32 {
+    return from + (to-from)*progress/total
+}
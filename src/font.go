@@ -0,0 +1,201 @@
+package main
+
+// Fonte bitmap 5x7 de largura variável.
+//
+// Cada glifo ocupa um uint64: os 35 bits de baixo guardam as 7 linhas de 5
+// pixels (a linha de cima nos bits mais altos, a coluna da esquerda no bit
+// mais alto de cada linha) e os bits 35-37 guardam a largura do glifo.
+// A tabela cobre o ASCII de ' ' a '_'; minúsculas usam a maiúscula e
+// qualquer outro byte vira FONT_FALLBACK.
+const (
+    FONT_HEIGHT = 7
+    FONT_COLUMNS = 5
+    FONT_SPACING = 1 // Pixels entre glifos
+    FONT_FIRST = ' '
+    FONT_LAST = '_'
+
+    FONT_WIDTH_SHIFT = FONT_HEIGHT * FONT_COLUMNS
+    FONT_FALLBACK = 4<<35 | 0b11110_10010_10010_10010_10010_10010_11110
+)
+
+var fontGlyphs = [FONT_LAST - FONT_FIRST + 1]uint64{
+    3<<35 | 0b00000_00000_00000_00000_00000_00000_00000, // ' '
+    1<<35 | 0b10000_10000_10000_10000_10000_00000_10000, // '!'
+    3<<35 | 0b10100_10100_00000_00000_00000_00000_00000, // '"'
+    5<<35 | 0b01010_01010_11111_01010_11111_01010_01010, // '#'
+    5<<35 | 0b00100_01111_10100_01110_00101_11110_00100, // '$'
+    5<<35 | 0b11000_11001_00010_00100_01000_10011_00011, // '%'
+    5<<35 | 0b01100_10010_10100_01000_10101_10010_01101, // '&'
+    1<<35 | 0b10000_10000_00000_00000_00000_00000_00000, // '\''
+    2<<35 | 0b01000_10000_10000_10000_10000_10000_01000, // '('
+    2<<35 | 0b10000_01000_01000_01000_01000_01000_10000, // ')'
+    3<<35 | 0b00000_10100_01000_11100_01000_10100_00000, // '*'
+    5<<35 | 0b00000_00100_00100_11111_00100_00100_00000, // '+'
+    2<<35 | 0b00000_00000_00000_00000_00000_01000_10000, // ','
+    3<<35 | 0b00000_00000_00000_11100_00000_00000_00000, // '-'
+    1<<35 | 0b00000_00000_00000_00000_00000_00000_10000, // '.'
+    5<<35 | 0b00001_00010_00010_00100_01000_01000_10000, // '/'
+    5<<35 | 0b01110_10001_10011_10101_11001_10001_01110, // '0'
+    3<<35 | 0b01000_11000_01000_01000_01000_01000_11100, // '1'
+    5<<35 | 0b01110_10001_00001_00010_00100_01000_11111, // '2'
+    5<<35 | 0b11110_00001_00001_01110_00001_00001_11110, // '3'
+    5<<35 | 0b00010_00110_01010_10010_11111_00010_00010, // '4'
+    5<<35 | 0b11111_10000_11110_00001_00001_10001_01110, // '5'
+    5<<35 | 0b00110_01000_10000_11110_10001_10001_01110, // '6'
+    5<<35 | 0b11111_00001_00010_00100_01000_01000_01000, // '7'
+    5<<35 | 0b01110_10001_10001_01110_10001_10001_01110, // '8'
+    5<<35 | 0b01110_10001_10001_01111_00001_00010_01100, // '9'
+    1<<35 | 0b00000_10000_00000_00000_00000_10000_00000, // ':'
+    2<<35 | 0b00000_01000_00000_00000_00000_01000_10000, // ';'
+    4<<35 | 0b00010_00100_01000_10000_01000_00100_00010, // '<'
+    4<<35 | 0b00000_00000_11110_00000_11110_00000_00000, // '='
+    4<<35 | 0b10000_01000_00100_00010_00100_01000_10000, // '>'
+    5<<35 | 0b01110_10001_00001_00010_00100_00000_00100, // '?'
+    5<<35 | 0b01110_10001_10111_10101_10111_10000_01110, // '@'
+    5<<35 | 0b01110_10001_10001_11111_10001_10001_10001, // 'A'
+    5<<35 | 0b11110_10001_10001_11110_10001_10001_11110, // 'B'
+    5<<35 | 0b01110_10001_10000_10000_10000_10001_01110, // 'C'
+    5<<35 | 0b11110_10001_10001_10001_10001_10001_11110, // 'D'
+    5<<35 | 0b11111_10000_10000_11110_10000_10000_11111, // 'E'
+    5<<35 | 0b11111_10000_10000_11110_10000_10000_10000, // 'F'
+    5<<35 | 0b01110_10001_10000_10111_10001_10001_01111, // 'G'
+    5<<35 | 0b10001_10001_10001_11111_10001_10001_10001, // 'H'
+    3<<35 | 0b11100_01000_01000_01000_01000_01000_11100, // 'I'
+    5<<35 | 0b00111_00010_00010_00010_00010_10010_01100, // 'J'
+    5<<35 | 0b10001_10010_10100_11000_10100_10010_10001, // 'K'
+    5<<35 | 0b10000_10000_10000_10000_10000_10000_11111, // 'L'
+    5<<35 | 0b10001_11011_10101_10101_10001_10001_10001, // 'M'
+    5<<35 | 0b10001_10001_11001_10101_10011_10001_10001, // 'N'
+    5<<35 | 0b01110_10001_10001_10001_10001_10001_01110, // 'O'
+    5<<35 | 0b11110_10001_10001_11110_10000_10000_10000, // 'P'
+    5<<35 | 0b01110_10001_10001_10001_10101_10010_01101, // 'Q'
+    5<<35 | 0b11110_10001_10001_11110_10100_10010_10001, // 'R'
+    5<<35 | 0b01111_10000_10000_01110_00001_00001_11110, // 'S'
+    5<<35 | 0b11111_00100_00100_00100_00100_00100_00100, // 'T'
+    5<<35 | 0b10001_10001_10001_10001_10001_10001_01110, // 'U'
+    5<<35 | 0b10001_10001_10001_10001_10001_01010_00100, // 'V'
+    5<<35 | 0b10001_10001_10001_10101_10101_10101_01010, // 'W'
+    5<<35 | 0b10001_10001_01010_00100_01010_10001_10001, // 'X'
+    5<<35 | 0b10001_10001_01010_00100_00100_00100_00100, // 'Y'
+    5<<35 | 0b11111_00001_00010_00100_01000_10000_11111, // 'Z'
+    2<<35 | 0b11000_10000_10000_10000_10000_10000_11000, // '['
+    5<<35 | 0b10000_01000_01000_00100_00010_00010_00001, // '\\'
+    2<<35 | 0b11000_01000_01000_01000_01000_01000_11000, // ']'
+    5<<35 | 0b00100_01010_10001_00000_00000_00000_00000, // '^'
+    5<<35 | 0b00000_00000_00000_00000_00000_00000_11111, // '_'
+}
+
+func glyphFor(char byte) uint64 {
+    if char >= 'a' && char <= 'z' {
+        char -= 'a' - 'A'
+    }
+    if char < FONT_FIRST || char > FONT_LAST {
+        return FONT_FALLBACK
+    }
+    return fontGlyphs[char-FONT_FIRST]
+}
+
+func charWidth(char byte) int32 {
+    return int32(glyphFor(char) >> FONT_WIDTH_SHIFT)
+}
+
+// Largura do texto em pixels, sem o espaçamento depois do último glifo
+func textWidth(text string) int32 {
+    if len(text) == 0 {
+        return 0
+    }
+    width := int32(0)
+    for i := 0; i < len(text); i++ {
+        width += charWidth(text[i]) + FONT_SPACING
+    }
+    return width - FONT_SPACING
+}
+
+func drawSimpleText(text string, x, y int32) {
+    for i := 0; i < len(text); i++ {
+        drawSimpleChar(text[i], x, y)
+        x += charWidth(text[i]) + FONT_SPACING
+    }
+}
+
+// Texto centralizado em x
+func drawTextCentered(text string, x, y int32) {
+    drawSimpleText(text, x-textWidth(text)/2, y)
+}
+
+// Texto terminando em x
+func drawTextRight(text string, x, y int32) {
+    drawSimpleText(text, x-textWidth(text), y)
+}
+
+// Desenha cada coluna do glifo como traços verticais, um rect por traço
+func drawSimpleChar(char byte, x, y int32) {
+    glyph := glyphFor(char)
+    width := int32(glyph >> FONT_WIDTH_SHIFT)
+    for col := int32(0); col < width; col++ {
+        start := int32(-1)
+        for row := int32(0); row <= FONT_HEIGHT; row++ {
+            on := false
+            if row < FONT_HEIGHT {
+                bit := uint(FONT_WIDTH_SHIFT - 1 - row*FONT_COLUMNS - col)
+                on = glyph&(1<<bit) != 0
+            }
+            if on && start < 0 {
+                start = row
+            } else if !on && start >= 0 {
+                rect(x+col, y+start, 1, row-start)
+                start = -1
+            }
+        }
+    }
+}
+
+// Algarismos de num, do último para o primeiro; devolve quantos são
+func numberDigits(num int32, digits *[10]byte) int {
+    n := 0
+    for {
+        digits[n] = '0' + byte(num%10)
+        n++
+        num /= 10
+        if num == 0 {
+            return n
+        }
+    }
+}
+
+func numberWidth(num int32) int32 {
+    var digits [10]byte
+    width := int32(0)
+    if num < 0 {
+        width = charWidth('-') + FONT_SPACING
+        num = -num
+    }
+    n := numberDigits(num, &digits)
+    for i := 0; i < n; i++ {
+        width += charWidth(digits[i]) + FONT_SPACING
+    }
+    return width - FONT_SPACING
+}
+
+// Número começando em x, com os mesmos glifos do texto
+func drawNumber(num, x, y int32) {
+    var digits [10]byte
+    if num < 0 {
+        drawSimpleChar('-', x, y)
+        x += charWidth('-') + FONT_SPACING
+        num = -num
+    }
+    for i := numberDigits(num, &digits) - 1; i >= 0; i-- {
+        drawSimpleChar(digits[i], x, y)
+        x += charWidth(digits[i]) + FONT_SPACING
+    }
+}
+
+// Número terminando em x
+func drawNumberRight(num, x, y int32) {
+    drawNumber(num, x-numberWidth(num), y)
+}
+
+func drawDigit(digit int, x, y int32) {
+    drawSimpleChar('0'+byte(digit), x, y)
+}
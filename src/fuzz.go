@@ -0,0 +1,70 @@
+//go:build !tinygo.wasm
+
+package main
+
+import (
+    "fmt"
+    "time"
+)
+
+// Fuzz do gerador de fases: para cada seed e cada faixa de dificuldade, gera
+// FUZZ_DISTANCE pixels de terreno e conta quantos trechos o validador
+// recusou e quantas vezes precisou consertar com chão plano. Sai com 1 se
+// alguma seed precisou de conserto, para servir de checagem na CI
+const FUZZ_DISTANCE = 20000
+
+// Pontuação que põe o jogo em cada difficultyTier (ver updateSpeeds)
+var fuzzTierScores = [DIFFICULTY_TIERS]int32{0, 100, 300, 500}
+
+func runFuzz(seeds int) int {
+    began := time.Now()
+    status := 0
+    for tier := 0; tier < DIFFICULTY_TIERS; tier++ {
+        var placed, rejected [len(levelChunks)]int32
+        repairs, failedSeeds := int32(0), 0
+        for seed := 1; seed <= seeds; seed++ {
+            beginRun(uint32(seed))
+            score = fuzzTierScores[tier]
+            updateSpeeds()
+            resetTerrain()
+            for cameraX = 0; cameraX < FUZZ_DISTANCE; cameraX += SCREEN_WIDTH {
+                extendTerrain()
+            }
+            for i := range levelChunks {
+                placed[i] += levelStats.placed[i]
+                rejected[i] += levelStats.rejected[i]
+            }
+            repairs += levelStats.repairs
+            if levelStats.repairs > 0 {
+                failedSeeds++
+            }
+        }
+
+        totalPlaced, totalRejected := int32(0), int32(0)
+        for i := range levelChunks {
+            totalPlaced += placed[i]
+            totalRejected += rejected[i]
+        }
+        fmt.Printf("tier %d: %d trechos, %d recusados (%.2f%%), %d consertos, %d/%d seeds com conserto\n",
+            tier, totalPlaced, totalRejected, percent(totalRejected, totalPlaced+totalRejected),
+            repairs, failedSeeds, seeds)
+        if repairs > 0 {
+            status = 1
+        }
+        for i := range levelChunks {
+            if rejected[i] > 0 {
+                fmt.Printf("    %-16s %6d recusados de %6d (%.2f%%)\n", levelChunks[i].name,
+                    rejected[i], placed[i]+rejected[i], percent(rejected[i], placed[i]+rejected[i]))
+            }
+        }
+    }
+    fmt.Printf("%d seeds em %v\n", seeds, time.Since(began).Round(time.Millisecond))
+    return status
+}
+
+func percent(part, whole int32) float64 {
+    if whole == 0 {
+        return 0
+    }
+    return 100 * float64(part) / float64(whole)
+}
@@ -0,0 +1,370 @@
+package main
+
+// Gerador de fases. O caminho é montado emendando trechos (chunks) escritos
+// à mão em levelChunks. Cada trecho é um desenho em texto, uma coluna de
+// TERRAIN_TILE pixels por caractere, em três linhas alinhadas:
+//
+//   spawns    o que aparece na coluna: g inimigo terrestre, f voador,
+//             r pedra, s espeto, p cápsula de power-up
+//   platform  altura da plataforma flutuante, em passos de TERRAIN_STEP
+//             acima de GROUND_Y ('1'-'9'); espaço = nenhuma
+//   ground    altura do chão, em passos de TERRAIN_STEP ('0'-'6');
+//             espaço = buraco. Vizinhos a um passo de distância viram rampa
+//
+// O sorteio usa o peso do trecho na dificuldade atual, e um filtro garante
+// que dois trechos seguidos sempre dão passagem (filtro em chunkFits). Depois
+// de emendado, o trecho ainda passa pelo validador (validate.go), que simula
+// o pulo na velocidade atual; se nenhum trecho passa, entra um pedaço plano.
+const (
+    DIFFICULTY_TIERS = 4
+    SPAWN_MARGIN = 30 // As entidades da coluna aparecem a essa distância da tela
+    REPAIR_COLUMNS = 8 // Chão plano que substitui um trecho sem passagem
+    COLUMN_ROLLS = 300 // Faixa do sorteio da coluna; múltiplo de 100, 30 e POWERUP_COUNT
+    CHUNK_MAX_COLUMNS = VALIDATE_PIXELS / TERRAIN_TILE // Trecho mais comprido que cabe no validador
+)
+
+type levelChunk struct {
+    name string
+    spawns string
+    platform string
+    ground string
+    weights [DIFFICULTY_TIERS]uint8 // Peso no sorteio por difficultyTier; 0 = fora
+}
+
+var levelChunks = [...]levelChunk{
+    {
+        name:     "plano",
+        spawns:   "",
+        platform: "",
+        ground:   "000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{3, 2, 1, 1},
+    },
+    {
+        name:     "terrestre",
+        spawns:   "          g     ",
+        platform: "",
+        ground:   "0000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{4, 3, 2, 2},
+    },
+    {
+        name:     "voador",
+        spawns:   "          f     ",
+        platform: "",
+        ground:   "0000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{3, 3, 2, 2},
+    },
+    {
+        name:     "espeto",
+        spawns:   "        s     ",
+        platform: "",
+        ground:   "00000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{3, 3, 3, 2},
+    },
+    {
+        name:     "pedras",
+        spawns:   "      r      r    ",
+        platform: "",
+        ground:   "000000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 3, 3, 3},
+    },
+    {
+        name:     "dois terrestres",
+        spawns:   "        g           g   ",
+        platform: "",
+        ground:   "000000000000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{1, 2, 3, 3},
+    },
+    {
+        name:     "dois voadores",
+        spawns:   "        f           f   ",
+        platform: "",
+        ground:   "000000000000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{1, 2, 3, 3},
+    },
+    {
+        name:     "buraco",
+        spawns:   "",
+        platform: "",
+        ground:   "000000  00000",
+        weights:  [DIFFICULTY_TIERS]uint8{3, 3, 3, 2},
+    },
+    {
+        name:     "buraco largo",
+        spawns:   "",
+        platform: "",
+        ground:   "000000   00000",
+        weights:  [DIFFICULTY_TIERS]uint8{0, 2, 3, 3},
+    },
+    {
+        name:     "ponte",
+        spawns:   "      p        ",
+        platform: "     444       ",
+        ground:   "0000       0000",
+        weights:  [DIFFICULTY_TIERS]uint8{1, 2, 2, 2},
+    },
+    {
+        name:     "plataforma",
+        spawns:   "       p        ",
+        platform: "      666       ",
+        ground:   "0000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 2, 2, 2},
+    },
+    {
+        name:     "degrau",
+        spawns:   "           g  ",
+        platform: "",
+        ground:   "00000444444444",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 2, 2, 2},
+    },
+    {
+        name:     "descida",
+        spawns:   "",
+        platform: "",
+        ground:   "44444440000000",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 2, 2, 2},
+    },
+    {
+        name:     "rampa",
+        spawns:   "             r  ",
+        platform: "",
+        ground:   "0000012344444444",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 2, 2, 2},
+    },
+    {
+        name:     "rampa abaixo",
+        spawns:   "",
+        platform: "",
+        ground:   "4444443210000000",
+        weights:  [DIFFICULTY_TIERS]uint8{2, 2, 2, 2},
+    },
+    {
+        name:     "mirante",
+        spawns:   "          f       ",
+        platform: "",
+        ground:   "444444466666666444",
+        weights:  [DIFFICULTY_TIERS]uint8{0, 1, 2, 2},
+    },
+    {
+        name:     "emboscada",
+        spawns:   "       f      g     ",
+        platform: "",
+        ground:   "00000000000000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{0, 1, 2, 3},
+    },
+    {
+        name:     "salto e espeto",
+        spawns:   "           s      ",
+        platform: "",
+        ground:   "00000  00000000000",
+        weights:  [DIFFICULTY_TIERS]uint8{0, 0, 2, 3},
+    },
+}
+
+var (
+    lastChunk int = -1      // Último trecho emendado
+    spawnColumn int32 = 0   // Primeira coluna cujas entidades ainda não apareceram
+
+    // Contagem do gerador desde resetLevel() (usada pelo -fuzz do host)
+    levelStats struct {
+        placed [len(levelChunks)]int32
+        rejected [len(levelChunks)]int32
+        repairs int32
+    }
+)
+
+func resetLevel() {
+    lastChunk = -1
+    spawnColumn = 0
+    levelStats.placed = [len(levelChunks)]int32{}
+    levelStats.rejected = [len(levelChunks)]int32{}
+    levelStats.repairs = 0
+}
+
+// Emenda o próximo trecho; no desafio, com a dificuldade do ponto em que
+// ele começa (seed.go)
+func spawnChunk() {
+    enterLevelDifficulty(terrainEnd * TERRAIN_TILE)
+    pickChunk()
+    leaveLevelDifficulty()
+}
+
+// Sorteia o próximo trecho entre os que cabem depois do último. Um trecho
+// que o validador recusa é desfeito e sai do sorteio desta vez
+func pickChunk() {
+    var rejected [len(levelChunks)]bool
+    var weights [len(levelChunks)]int32
+    for {
+        total := int32(0)
+        for i := range levelChunks {
+            weights[i] = 0
+            if !rejected[i] {
+                weights[i] = chunkWeight(i)
+            }
+            total += weights[i]
+        }
+        if total == 0 {
+            repairChunk()
+            return
+        }
+        pick := levelRand.intn(total)
+        for i := range levelChunks {
+            if pick -= weights[i]; pick < 0 {
+                start := terrainEnd
+                // O trecho é escrito no buffer circular por cima das colunas
+                // mais antigas; guarda elas para desfazer se for recusado
+                var saved [CHUNK_MAX_COLUMNS]terrainColumn
+                n := int32(len(levelChunks[i].ground))
+                for col := int32(0); col < n; col++ {
+                    saved[col] = terrain[(start+col)%TERRAIN_COLUMNS]
+                }
+                placeChunk(i)
+                if terrainPassable(start) {
+                    lastChunk = i
+                    levelStats.placed[i]++
+                    return
+                }
+                for col := int32(0); col < n; col++ {
+                    terrain[(start+col)%TERRAIN_COLUMNS] = saved[col]
+                }
+                terrainEnd = start
+                rejected[i] = true
+                levelStats.rejected[i]++
+                break
+            }
+        }
+    }
+}
+
+// Nenhum trecho deu passagem: segue plano na altura em que o último terminou
+func repairChunk() {
+    ground := terrainColumnAt(terrainEnd - 1).ground
+    if ground == TERRAIN_PIT {
+        ground = GROUND_Y
+    }
+    for i := 0; i < REPAIR_COLUMNS; i++ {
+        addColumn(terrainColumn{ground: ground})
+    }
+    lastChunk = -1
+    levelStats.repairs++
+}
+
+func chunkWeight(i int) int32 {
+    if !chunkFits(lastChunk, i) {
+        return 0
+    }
+    return int32(levelChunks[i].weights[difficultyTier])
+}
+
+// Filtro entre trechos seguidos: o começo do próximo não pode ser mais alto
+// que um degrau acima do fim do anterior, dois trechos difíceis não vêm
+// colados e o mesmo trecho não se repete
+func chunkFits(prev, next int) bool {
+    if prev < 0 {
+        return true
+    }
+    a, b := &levelChunks[prev], &levelChunks[next]
+    if prev == next || chunkHard(a) && chunkHard(b) {
+        return false
+    }
+    return chunkHeight(b.ground, 0)-chunkHeight(a.ground, len(a.ground)-1) <= TERRAIN_LEDGE
+}
+
+// Trecho com buraco ou espeto (erro que mata)
+func chunkHard(ch *levelChunk) bool {
+    return hasByte(ch.ground, ' ') || hasByte(ch.spawns, 's')
+}
+
+func hasByte(s string, b byte) bool {
+    for i := 0; i < len(s); i++ {
+        if s[i] == b {
+            return true
+        }
+    }
+    return false
+}
+
+// Altura em pixels acima de GROUND_Y na coluna i de uma linha do desenho,
+// ou -1 sem nada
+func chunkHeight(row string, i int) int32 {
+    if i >= len(row) || row[i] < '0' || row[i] > '9' {
+        return -1
+    }
+    return int32(row[i]-'0') * TERRAIN_STEP
+}
+
+// Copia o desenho do trecho para o fim do terreno
+func placeChunk(i int) {
+    ch := &levelChunks[i]
+    for col := 0; col < len(ch.ground); col++ {
+        c := terrainColumn{ground: TERRAIN_PIT}
+        if h := chunkHeight(ch.ground, col); h >= 0 {
+            c.ground = int16(GROUND_Y - h)
+            next := chunkHeight(ch.ground, col+1)
+            if next == h+TERRAIN_STEP || next == h-TERRAIN_STEP {
+                c.slope = int8(h - next)
+            }
+        }
+        if h := chunkHeight(ch.platform, col); h > 0 {
+            c.platform = int16(GROUND_Y - h)
+        }
+        if col < len(ch.spawns) && ch.spawns[col] != ' ' {
+            c.spawn = ch.spawns[col]
+            c.roll = uint16(levelRand.intn(COLUMN_ROLLS))
+        }
+        addColumn(c)
+    }
+}
+
+// Pedra ou espeto da coluna, como releaseSpawns() vai soltar
+func columnObstacle(col int32) (entity, bool) {
+    x := col * TERRAIN_TILE
+    ground := columnSurface(col)
+    switch terrainColumnAt(col).spawn {
+    case 'r':
+        return entity{kind: KIND_ROCK, x: x, y: ground - 8, width: 8, height: 8}, true
+    case 's':
+        return entity{kind: KIND_SPIKE, x: x + 1, y: ground - 8, width: 6, height: 8}, true
+    }
+    return entity{}, false
+}
+
+// Chão mais alto da coluna, onde as entidades dela se apoiam
+func columnSurface(col int32) int32 {
+    ground, ok := surfaceY(col*TERRAIN_TILE, TERRAIN_TILE)
+    if !ok {
+        return GROUND_Y
+    }
+    return ground
+}
+
+// Solta as entidades das colunas que chegaram perto da borda da tela. O que
+// varia entre elas vem do sorteio da coluna, feito quando ela foi gerada
+func releaseSpawns() {
+    for ; spawnColumn*TERRAIN_TILE < cameraX+SCREEN_WIDTH+SPAWN_MARGIN; spawnColumn++ {
+        c := terrainColumnAt(spawnColumn)
+        if c.spawn == 0 {
+            continue
+        }
+        x := spawnColumn * TERRAIN_TILE
+        ground := columnSurface(spawnColumn)
+        roll := int32(c.roll)
+        enterLevelDifficulty(x)
+        switch c.spawn {
+        case 'g':
+            spawnEnemy(x, ground-12, groundEnemyKind(roll%100))
+        case 'f':
+            spawnEnemy(x, 70+roll%30, flyingEnemyKind(roll%100))
+        case 'r', 's':
+            e, _ := columnObstacle(spawnColumn)
+            spawnObstacle(e.x, e.y, e.width, e.height, e.kind)
+        case 'p':
+            // Na altura de um pulo, ou logo acima da plataforma
+            y := ground - 30
+            if c.platform != 0 {
+                y = int32(c.platform) - SPRITE_PICKUP_HEIGHT - 3
+            }
+            spawnPickup(x, y, int8(roll%POWERUP_COUNT))
+        }
+        leaveLevelDifficulty()
+    }
+}
@@ -1,15 +1,5 @@
 package main
 
-import "unsafe"
-
-// WASM-4 Memory-mapped I/O
-var (
-    DRAW_COLORS = (*uint16)(unsafe.Pointer(uintptr(0x14)))
-    GAMEPAD1    = (*uint8)(unsafe.Pointer(uintptr(0x16)))
-    PALETTE     = (*[4]uint32)(unsafe.Pointer(uintptr(0x04)))
-    MOUSE_BUTTONS = (*uint8)(unsafe.Pointer(uintptr(0x1e)))
-)
-
 // Constantes otimizadas
 const (
     SCREEN_WIDTH  = 160
@@ -28,195 +18,157 @@ const (
     STATE_MENU = 0
     STATE_PLAYING = 1
     STATE_GAME_OVER = 2
+    STATE_PAUSED = 3
     
     // Jogador
     PLAYER_WIDTH = 8
     PLAYER_HEIGHT = 12
+    PLAYER_MAX_HP = 3
+    PLAYER_INVULN_FRAMES = 60 // Invencível depois de levar dano
+    
+    // Física do jogador (ponto fixo 8.8, ver fixed.go)
+    PLAYER_GRAVITY = FIXED_ONE
+    PLAYER_HOLD_GRAVITY = FIXED_ONE / 2 // Com o pulo apertado, no começo da subida
+    PLAYER_JUMP_HOLD_FRAMES = 8         // Quanto tempo segurar ainda aumenta o pulo
+    PLAYER_JUMP_CUT_VELOCITY = 3 * FIXED_ONE // Subida máxima depois de soltar o pulo
+    PLAYER_COYOTE_FRAMES = 6      // Ainda dá para pular logo depois de sair do chão
+    PLAYER_JUMP_BUFFER_FRAMES = 6 // Pulo apertado antes de pousar sai no pouso
+    PLAYER_TERMINAL_VELOCITY = 8 * FIXED_ONE
+    PLAYER_ACCEL = FIXED_ONE / 16 // Até chegar em currentPlayerSpeed
+    
+    // Dificuldade: as velocidades crescem em rampa até DIFFICULTY_RAMP_SCORE
+    DIFFICULTY_RAMP_SCORE = 500
+    PLAYER_SPEED_START = FIXED_ONE
+    PLAYER_SPEED_MAX = 3 * FIXED_ONE
+    ENEMY_SPEED_START = FIXED_ONE
+    ENEMY_SPEED_MAX = 2 * FIXED_ONE
+    BULLET_SPEED_START = 3 * FIXED_ONE
+    BULLET_SPEED_MAX = 5 * FIXED_ONE
+    JUMP_POWER_START = -9 * FIXED_ONE
+    JUMP_POWER_MAX = -15 * FIXED_ONE / 2 // Pulo mais curto quando tudo está mais rápido
+    
+    // Co-op
+    MAX_PLAYERS = 4
+    PLAYER_SPACING = 12 // Distância inicial entre jogadores
+    NETPLAY_ACTIVE = 4  // Bit do registrador NETPLAY
+    COOP_AMMO_X = 20    // Linha do HUD: balas,
+    COOP_HEARTS_X = 84  // corações
+    COOP_AMMO_GAP = 2   // e a folga entre eles
     
     // Munição
-    MAX_BULLETS = 4
+    MAX_BULLETS = 4 // Por jogador
+    MAX_PLAYER_BULLETS = MAX_BULLETS * MAX_PLAYERS
     MAX_AMMO = 8
     RELOAD_TIME = 120
     BULLET_WIDTH = 4
     BULLET_HEIGHT = 2
+    BULLET_DIAGONAL = 3 // Lado da caixa do tiro na diagonal
+    BULLET_SPEED = 5 * FIXED_ONE
+    BULLET_DIAGONAL_SPEED = 905 // BULLET_SPEED / √2
 
     // Tiro inimigo
-    MAX_ENEMY_BULLETS = 6
     ENEMY_BULLET_WIDTH = 3
     ENEMY_BULLET_HEIGHT = 3
     ENEMY_SHOOT_RATE = 30
-    
-    // Inimigos
-    MAX_ENEMIES = 3
-    ENEMY_GROUND = 0
-    ENEMY_FLYING = 1
-    
-    // Obstaculos
-    MAX_OBSTACLES = 3
-    OBSTACLE_ROCK = 0
-    OBSTACLE_SPIKE = 1
-
-    // Constantes para mira
-    AIM_HORIZONTAL = 0
-    AIM_VERTICAL   = 1
+
+
+    // Constantes para mira: oito direções, no sentido anti-horário.
+    // Para baixo só no ar; no chão Baixo + Botão 2 continua pausando
+    AIM_RIGHT = 0
+    AIM_UP_RIGHT = 1
+    AIM_UP = 2
+    AIM_UP_LEFT = 3
+    AIM_LEFT = 4
+    AIM_DOWN_LEFT = 5
+    AIM_DOWN = 6
+    AIM_DOWN_RIGHT = 7
+    AIM_COUNT = 8
     
     // Botões de direção
     BUTTON_LEFT  = 16
     BUTTON_RIGHT = 32
     BUTTON_UP    = 64
     BUTTON_DOWN  = 128
-    
-    // Particulas
-    MAX_PARTICLES = 4
-
-    // Procedural
-    PATTERN_EASY = 0
-    PATTERN_JUMP = 1
-    PATTERN_SHOOT = 2
 )
 
-// Sprites
-var (
-    // Jogador (8x12) - dois frames: parado e correndo
-    playerSprite = [2][12]uint8{
-        // Frame 0 - parado
-        {
-            0b00011000, // cabeça
-            0b00111100, // cabeça
-            0b00011000, // pescoço
-            0b00111100, // ombros
-            0b01111110, // torso
-            0b00111100, // cintura
-            0b00111100, // quadril
-            0b00111100, // pernas
-            0b01111110, // pernas
-            0b01111110, // pernas
-            0b01100110, // pés
-            0b11100111, // pés
-        },
-        // Frame 1 - correndo
-        {
-            0b00011000, // cabeça
-            0b00111100, // cabeça
-            0b00011000, // pescoço
-            0b00111100, // ombros
-            0b01111110, // torso
-            0b00111100, // cintura
-            0b00111100, // quadril
-            0b00111100, // pernas
-            0b01111110, // pernas
-            0b01111110, // pernas
-            0b11001100, // pés correndo
-            0b11001100, // pés correndo
-        },
-    }
-
-    // Sprite da bala para UI (4x2)
-    bulletSprite = [2]uint8{
-        0b11110000, // corpo da bala
-        0b11110000, // corpo da bala
-    }
-    
-    // Sprite da arma (6x3) - pistola
-    weaponSprite = [3]uint8{
-        0b11111100, // cano
-        0b11000000, // corpo
-        0b11000000, // cabo
-    }
-
-    // Sprite da arma vertical (3x6) - pistola apontando para cima
-    weaponVerticalSprite = [6]uint8{
-        0b11000000, // cabo
-        0b11000000, // cabo
-        0b11000000, // corpo
-        0b11000000, // corpo
-        0b11100000, // cano
-        0b11100000, // cano
-    }
-    
-    // Sprite do inimigo terrestre (8x12)
-    groundEnemySprite = [12]uint8{
-        0b00111100, // antenas
-        0b01111110, // cabeça
-        0b11100111, // olhos
-        0b01111110, // cabeça
-        0b11111111, // ombros
-        0b01111110, // torso
-        0b11111111, // torso
-        0b01111110, // cintura
-        0b11111111, // pernas
-        0b01111110, // pernas
-        0b11111111, // pés
-        0b10100101, // rodas
-    }
-    
-    // Sprite do inimigo aéreo (8x8)
-    flyingEnemySprite = [8]uint8{
-        0b10100101, // hélices
-        0b01111110, // corpo
-        0b11111111, // corpo
-        0b11100111, // sensores
-        0b11111111, // corpo
-        0b01111110, // corpo
-        0b00111100, // base
-        0b10100101, // hélices
-    }
-    
-    // Obstáculo - Pedra (8x8)
-    rockSprite = [8]uint8{
-        0b00111100,
-        0b01111110,
-        0b11111111,
-        0b11111111,
-        0b11111111,
-        0b11111111,
-        0b01111110,
-        0b00111100,
-    }
-    
-    // Obstáculo - Espeto (6x8)
-    spikeSprite = [8]uint8{
-        0b00100000,
-        0b01110000,
-        0b11111000,
-        0b11111000,
-        0b11111000,
-        0b11111000,
-        0b11111000,
-        0b11111000,
-    }
-)
+// Cor de cada jogador
+var playerColors = [MAX_PLAYERS]uint16{0x03, 0x04, 0x03, 0x04}
 
-// Variável global para controlar direção da mira
-var aimDirection int8 = AIM_HORIZONTAL
+// Direção da mira pelo direcional, indexada por [dy+1][dx+1]
+var aimFromPad = [3][3]int8{
+    {AIM_UP_LEFT, AIM_UP, AIM_UP_RIGHT},
+    {AIM_LEFT, -1, AIM_RIGHT},
+    {AIM_DOWN_LEFT, AIM_DOWN, AIM_DOWN_RIGHT},
+}
+
+// Velocidade do tiro em cada direção
+var aimVelocities = [AIM_COUNT][2]int16{
+    AIM_RIGHT: {BULLET_SPEED, 0},
+    AIM_UP_RIGHT: {BULLET_DIAGONAL_SPEED, -BULLET_DIAGONAL_SPEED},
+    AIM_UP: {0, -BULLET_SPEED},
+    AIM_UP_LEFT: {-BULLET_DIAGONAL_SPEED, -BULLET_DIAGONAL_SPEED},
+    AIM_LEFT: {-BULLET_SPEED, 0},
+    AIM_DOWN_LEFT: {-BULLET_DIAGONAL_SPEED, BULLET_DIAGONAL_SPEED},
+    AIM_DOWN: {0, BULLET_SPEED},
+    AIM_DOWN_RIGHT: {BULLET_DIAGONAL_SPEED, BULLET_DIAGONAL_SPEED},
+}
+
+// Boca do cano, relativa ao canto do jogador
+var aimMuzzles = [AIM_COUNT][2]int32{
+    AIM_RIGHT: {PLAYER_WIDTH, 4},
+    AIM_UP_RIGHT: {PLAYER_WIDTH + 1, -3},
+    AIM_UP: {4, -2},
+    AIM_UP_LEFT: {-4, -3},
+    AIM_LEFT: {-BULLET_WIDTH, 4},
+    AIM_DOWN_LEFT: {-4, PLAYER_HEIGHT},
+    AIM_DOWN: {3, PLAYER_HEIGHT + 2},
+    AIM_DOWN_RIGHT: {PLAYER_WIDTH + 1, PLAYER_HEIGHT},
+}
+
+// Arma em cada direção: posição relativa ao jogador, sprite e flags do blit.
+// As diagonais usam o sprite inclinado; as demais, o reto girado ou espelhado
+var weaponPoses = [AIM_COUNT]struct {
+    dx, dy int32
+    sprite int
+    flags uint32
+}{
+    AIM_RIGHT: {8, 4, SPRITE_WEAPON, 0},
+    AIM_UP_RIGHT: {7, -1, SPRITE_WEAPON_DIAG, 0},
+    AIM_UP: {3, -2, SPRITE_WEAPON, BLIT_ROTATE},
+    AIM_UP_LEFT: {-4, -1, SPRITE_WEAPON_DIAG, BLIT_FLIP_X},
+    AIM_LEFT: {-6, 4, SPRITE_WEAPON, BLIT_FLIP_X},
+    AIM_DOWN_LEFT: {-4, 8, SPRITE_WEAPON_DIAG, BLIT_FLIP_X | BLIT_FLIP_Y},
+    AIM_DOWN: {3, 8, SPRITE_WEAPON, BLIT_ROTATE | BLIT_FLIP_X},
+    AIM_DOWN_RIGHT: {7, 8, SPRITE_WEAPON_DIAG, BLIT_FLIP_Y},
+}
+
+var aimNames = [AIM_COUNT]string{
+    AIM_RIGHT: "FWD",
+    AIM_UP_RIGHT: "UP-FWD",
+    AIM_UP: "UP",
+    AIM_UP_LEFT: "UP-BACK",
+    AIM_LEFT: "BACK",
+    AIM_DOWN_LEFT: "DN-BACK",
+    AIM_DOWN: "DOWN",
+    AIM_DOWN_RIGHT: "DN-FWD",
+}
 
 // Cooldown
 var gameOverTimer uint8
 var previousGamepadState uint8
 
-// Variáveis globais para controle de entrada
+// Sistema de velocidade progressiva (ponto fixo 8.8)
 var (
-    prevMouseButtons uint8 = 0
-    prevGamepad uint8 = 0
-)
-
-// Sistema de velocidade progressiva
-var (
-    currentPlayerSpeed int32 = 1
-    currentEnemySpeed int32 = 1
-    currentBulletSpeed int32 = 3
-    currentJumpPower int8 = -11
+    currentPlayerSpeed int16 = PLAYER_SPEED_START
+    currentEnemySpeed int16 = ENEMY_SPEED_START
+    currentBulletSpeed int16 = BULLET_SPEED_START
+    currentJumpPower int16 = JUMP_POWER_START
+    difficultyTier int8 = 0 // 0 = inicial, 3 = extremo
 )
 
 // Sistema de geração procedural
 var (
-    rngSeed uint32 = 12345 // Seed inicial
-    spawnTimer int32 = 0
-    nextSpawnDelay int32 = 60
-    lastSpawnType int8 = -1 // Controla tipos consecutivos
-    lastSpawnX int32 = 0    // Controla proximidade
-    currentPattern int8 = PATTERN_EASY
-    patternTimer int32 = 0
+    runSeed uint32 = 12345 // Seed da partida (rand.go)
     gameStartRealTime int32 = 0
     frameCounter int32 = 0
 )
@@ -230,74 +182,61 @@ var (
     highScore int32 = 0
 )
 
-// Sistema de munição
-var (
-    ammo int32 = MAX_AMMO
-    reloadTimer int32 = 0
-    isReloading bool = false
-)
-
-// Jogador
-var player struct {
+// Corpo do jogador: o que a física move. Fica separado para o validador
+// da fase (validate.go) simular exatamente o mesmo pulo
+type playerBody struct {
     x, y      int32
-    velY      int8
+    subX, subY uint8 // Fração da posição (fixed.go)
+    velX, velY int16 // Ponto fixo 8.8
+    jumpHold  uint8  // Quadros restantes de gravidade reduzida do pulo
+    coyote    uint8  // Quadros que ainda valem como chão depois de sair dele
     flags     uint8 // bit 0: onGround, bit 1: alive
-    animFrame int8
 }
 
-// Tiros
-var bullets [MAX_BULLETS]struct {
-    x, y        int32
-    velX, velY  int8
-    active      bool
-}
+// Jogadores - um por gamepad; o jogador 1 sempre participa
+var players [MAX_PLAYERS]struct {
+    playerBody
+    jumpBuffer uint8 // Quadros que um pulo apertado ainda espera o chão
+    animFrame int8
+    joined    bool
+    aimDirection int8
+    score     int32 // Pontos feitos por este jogador
 
-// Inimigos
-var enemies [MAX_ENEMIES]struct {
-    x, y        int32
-    velX, velY  int8
-    enemyType   int8
-    active      bool
-    animFrame   int8
-}
+    // Sistema de munição
+    ammo        int32
+    reloadTimer int32
+    isReloading bool
 
-// Tiros inimigos
-var enemyBullets [MAX_ENEMY_BULLETS]struct {
-    x, y   int32
-    velX, velY int8
-    active bool
-}
+    // Vida
+    hp     int8
+    invuln uint8 // Quadros restantes de invencibilidade
 
-// Obstáculos
-var obstacles [MAX_OBSTACLES]struct {
-    x, y        int32
-    width, height int8
-    obstacleType int8
-    active      bool
-}
+    powerups [POWERUP_COUNT]uint16 // Quadros restantes de cada efeito
 
-// Partículas
-var particles [MAX_PARTICLES]struct {
-    x, y       int32
-    velX, velY int8
-    life       int8
-    active     bool
+    // Entrada do quadro anterior
+    prevGamepad      uint8
+    prevMouseButtons uint8
 }
 
 //go:export start
 func start() {
-    PALETTE[0] = 0x1a1c2c // Azul escuro (fundo)
-    PALETTE[1] = 0x1d2b53 // Verde petróleo (chão)
-    PALETTE[2] = 0xab1c2f // Vermelho (inimigos/player)
-    PALETTE[3] = 0xf4a261 // Laranja queimado (obstáculos)
+    screen.SetPalette([4]uint32{
+        0x1a1c2c, // Azul escuro (fundo)
+        0x1d2b53, // Verde petróleo (chão)
+        0xab1c2f, // Vermelho (inimigos/player)
+        0xf4a261, // Laranja queimado (obstáculos)
+    })
     
+    loadSave()
+    players[0].joined = true
     initGame()
 }
 
 //go:export update
 func update() {
     frameCounter++
-    gameFrame++
+    updateSfx()
+    updateMouseAim()
     
     switch gameState {
     case STATE_MENU:
@@ -306,182 +245,151 @@ func update() {
         updateGame()
     case STATE_GAME_OVER:
         updateGameOver()
+    case STATE_PAUSED:
+        updatePause()
     }
     
+    updateMusic()
     draw()
 }
 
 // Inicialização
 func initGame() {
-    initPlayer()
-    clearArrays()
-}
-
-// Inicia Jogador
-func initPlayer() {
-    player.x = 20
-    player.y = GROUND_Y - PLAYER_HEIGHT
-    player.velY = 0
-    player.flags = 0x03 // onGround=1, alive=1
-    player.animFrame = 0
+    initPlayers()
+    clearEntities()
 }
 
-func clearArrays() {
-    for i := 0; i < MAX_BULLETS; i++ {
-        bullets[i].active = false
-        bullets[i].velY = 0
-    }
-    for i := 0; i < MAX_ENEMY_BULLETS; i++ {
-        enemyBullets[i].active = false
-    }
-    for i := 0; i < MAX_ENEMIES; i++ {
-        enemies[i].active = false
-    }
-    for i := 0; i < MAX_OBSTACLES; i++ {
-        obstacles[i].active = false
-    }
-    for i := 0; i < MAX_PARTICLES; i++ {
-        particles[i].active = false
+// Inicia os jogadores; os que entraram largam em fila, o jogador 1 na frente
+func initPlayers() {
+    count := joinedCount()
+    slot := int32(0)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        players[i].x = 20 + PLAYER_SPACING*(count-1-slot)
+        players[i].y = GROUND_Y - PLAYER_HEIGHT
+        players[i].subX, players[i].subY = 0, 0
+        players[i].velX, players[i].velY = 0, 0 // Acelera na largada
+        players[i].jumpHold = 0
+        players[i].coyote, players[i].jumpBuffer = 0, 0
+        players[i].flags = 0x01 // onGround=1, alive=0
+        players[i].animFrame = 0
+        players[i].aimDirection = AIM_RIGHT
+        players[i].score = 0
+        players[i].ammo = MAX_AMMO
+        players[i].reloadTimer = 0
+        players[i].isReloading = false
+        players[i].hp = PLAYER_MAX_HP
+        players[i].invuln = 0
+        players[i].powerups = [POWERUP_COUNT]uint16{}
+        if players[i].joined {
+            players[i].flags = 0x03 // onGround=1, alive=1
+            slot++
+        }
     }
 }
 
-// Velocidades para níveis de dificuldade
-func updateSpeeds() {
-    if score >= 500 {
-        // Nível extremo
-        currentPlayerSpeed = 3
-        currentEnemySpeed = 2
-        currentBulletSpeed = 5
-        currentJumpPower = -9
-    } else if score >= 300 {
-        // Nível difícil
-        currentPlayerSpeed = 2
-        currentEnemySpeed = 2
-        currentBulletSpeed = 5
-        currentJumpPower = -10
-    } else if score >= 100 {
-        // Nível médio
-        currentPlayerSpeed = 2
-        currentEnemySpeed = 1
-        currentBulletSpeed = 3
-        currentJumpPower = -10
-    }
-    // else: mantém velocidades iniciais (pulo mais forte para compensar velocidade baixa)
+// Quantidade de jogadores na partida
+func joinedCount() int32 {
+    count := int32(0)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if players[i].joined {
+            count++
+        }
+    }
+    return count
 }
 
-// Gerador de números pseudo-aleatórios simples
-func rng() uint32 {
-	rngSeed = (rngSeed*1664525 + 1013904223) & 0x7FFFFFFF
-	// Melhora a distribuição com XOR shift
-	rngSeed = rngSeed ^ (rngSeed >> 16)
-	rngSeed = rngSeed ^ (rngSeed << 3)
-	return rngSeed
+// Jogador vivo mais à frente, ou -1 se todos morreram
+func leadPlayer() int {
+    lead := -1
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if (players[i].flags&0x02) != 0 && (lead < 0 || players[i].x > players[lead].x) {
+            lead = i
+        }
+    }
+    return lead
 }
 
-// Retorna um número entre 0 e max-1
-func randInt(max int32) int32 {
-	// Chama rng() duas vezes para melhor distribuição
-	val1 := rng()
-	val2 := rng()
-	combined := (val1 + val2) / 2
-	return int32(combined % uint32(max))
+// Velocidades sobem em rampa com a pontuação; os níveis ficam para a
+// escolha de inimigos e para a música
+func updateSpeeds() {
+    setDifficulty(runProgress())
 }
 
-// Função para embaralhar ainda mais a seed durante o jogo
-func shuffleSeed() {
-	rngSeed = rngSeed ^ uint32(gameFrame)
-	rngSeed = (rngSeed << 7) ^ (rngSeed >> 25)
-	rngSeed = rngSeed ^ uint32(score*3 + int32(player.x/10))
+// Velocidades e difficultyTier para um progresso (pontos; ver runProgress)
+func setDifficulty(progress int32) {
+    ramped := clamp32(progress, 0, DIFFICULTY_RAMP_SCORE)
+    currentPlayerSpeed = int16(ramp(PLAYER_SPEED_START, PLAYER_SPEED_MAX, ramped, DIFFICULTY_RAMP_SCORE))
+    currentEnemySpeed = int16(ramp(ENEMY_SPEED_START, ENEMY_SPEED_MAX, ramped, DIFFICULTY_RAMP_SCORE))
+    currentBulletSpeed = int16(ramp(BULLET_SPEED_START, BULLET_SPEED_MAX, ramped, DIFFICULTY_RAMP_SCORE))
+    currentJumpPower = int16(ramp(JUMP_POWER_START, JUMP_POWER_MAX, ramped, DIFFICULTY_RAMP_SCORE))
+    
+    if progress >= 500 {
+        difficultyTier = 3 // Nível extremo
+    } else if progress >= 300 {
+        difficultyTier = 2 // Nível difícil
+    } else if progress >= 100 {
+        difficultyTier = 1 // Nível médio
+    } else {
+        difficultyTier = 0
+    }
 }
 
-// Gera aleatoriedade no design do jogo
+// Gera aleatoriedade no design do jogo: emenda trechos (level.go) à frente
+// da câmera e solta o que eles trazem quando chegam perto da tela
 func proceduralSpawn() {
-	patternTimer++
-	
-	// Muda padrão a cada 3 segundos (300 frames a 60fps)
-	patternChangeTime := 180
-	if patternTimer >= int32(patternChangeTime) {
-		patternTimer = 0
-		
-		// Embaralha a seed antes de mudar padrão
-		shuffleSeed()
-		
-		// Evita repetir o mesmo padrão
-		newPattern := int8(randInt(3)) // 0, 1, 2 (EASY, JUMP, SHOOT)
-		if newPattern >= currentPattern {
-			newPattern = int8((newPattern + 1) % 3)
-		}
-		currentPattern = newPattern
-		
-		// Spawna UMA VEZ baseado no novo padrão
-		baseSpawnX := cameraX + SCREEN_WIDTH + 30
-		
-		// Spawn baseado no padrão atual
-		switch currentPattern {
-		case PATTERN_EASY:
-			spawnEasyPattern(baseSpawnX)
-		case PATTERN_JUMP:
-			spawnJumpPattern(baseSpawnX)
-		case PATTERN_SHOOT:
-			spawnShootPattern(baseSpawnX)
-		}
-		
-		lastSpawnX = baseSpawnX
-	}
+	extendTerrain()
+	releaseSpawns()
 }
 
-func spawnEasyPattern(x int32) {
-	choice := randInt(100)
-	if choice < 30 {
-		// Só inimigo terrestre
-		spawnEnemy(x, GROUND_Y-12, ENEMY_GROUND)
-	} else if choice < 60 {
-		// Só inimigo voador
-		spawnEnemy(x, 80 + randInt(20), ENEMY_FLYING)
-	} else {
-		// Um obstáculo
-		spawnObstacle(x, GROUND_Y-8, 6, 8, OBSTACLE_SPIKE)
+// Com a dificuldade, parte dos inimigos vem na versão mais resistente;
+// roll (0-99) é o sorteio da coluna
+func groundEnemyKind(roll int32) int8 {
+	if difficultyTier >= 1 && roll < 20*int32(difficultyTier) {
+		return KIND_HEAVY_ENEMY
 	}
+	return KIND_GROUND_ENEMY
 }
 
-func spawnJumpPattern(x int32) {
-	choice := randInt(100)
-	if choice < 40 {
-		spawnObstacle(x, GROUND_Y-8, 8, 8, OBSTACLE_ROCK)
-	} else if choice < 60 {
-		spawnObstacle(x, GROUND_Y-8, 6, 8, OBSTACLE_SPIKE)
-	} else if choice < 80 {
-		spawnObstacle(x, GROUND_Y-8, 8, 8, OBSTACLE_ROCK)
-	} else {
-		obstacleType := OBSTACLE_ROCK
-		if randInt(2) == 0 {
-			obstacleType = OBSTACLE_SPIKE
-		}
-		spawnObstacle(x, GROUND_Y-8, 8, 8, int8(obstacleType))
-	}
-}
-
-func spawnShootPattern(x int32) {
-	choice := randInt(100)
-	if choice < 25 {
-		// Um inimigo voador apenas
-		spawnEnemy(x, 70 + randInt(30), ENEMY_FLYING)
-	} else if choice < 50 {
-		spawnEnemy(x, GROUND_Y-12, ENEMY_GROUND)
-	} else if choice < 75 {
-		// Dois inimigos voadores em alturas diferentes
-		spawnEnemy(x, 60 + randInt(20), ENEMY_FLYING)
-		spawnEnemy(x + 150 + randInt(80), 90 + randInt(20), ENEMY_FLYING)
-	} else {
-		// Dois inimigos terrestres
-		spawnEnemy(x, GROUND_Y-12, ENEMY_GROUND)
-		spawnEnemy(x + 120 + randInt(70), GROUND_Y-12, ENEMY_GROUND)
+func flyingEnemyKind(roll int32) int8 {
+	if difficultyTier >= 2 && roll < 25*int32(difficultyTier-1) {
+		return KIND_SHIELDED_ENEMY
 	}
+	return KIND_FLYING_ENEMY
 }
 
 func updateMenu() {
-    gamepad := *GAMEPAD1
+    gamepad := input.Gamepad(0)
+    pressed := gamepad &^ menuPrevGamepad
+    menuPrevGamepad = gamepad
+    
+    if codeEditing {
+        updateCodeEditor(pressed)
+        return
+    }
+    // Depois de fechar o editor, o botão que fechou não inicia a partida
+    if codeClosing {
+        codeClosing = gamepad&(BUTTON_1|BUTTON_2) != 0
+        return
+    }
+    if pressed&BUTTON_RIGHT != 0 {
+        openCodeEditor()
+        return
+    }
+    
+    // Jogadores 2-4 entram apertando qualquer botão
+    for i := 1; i < MAX_PLAYERS; i++ {
+        if input.Gamepad(i)&(BUTTON_1|BUTTON_2) != 0 {
+            players[i].joined = true
+        }
+    }
     
+    // Reproduz a última partida gravada
+    if gamepad&BUTTON_DOWN != 0 && replayAvailable() {
+        gameState = STATE_PLAYING
+        startPlayback()
+        return
+    }
+
     if gamepad&(BUTTON_1|BUTTON_2) != 0 {
         gameState = STATE_PLAYING
         startGame()
@@ -489,51 +397,77 @@ func updateMenu() {
 }
 
 func updateGame() {
+    if pauseRequested() {
+        enterPause()
+        return
+    }
+    if playbackEnded() {
+        enterGameOver()
+        return
+    }
+    // Conta só os quadros simulados: os pausados não entram no replay
+    gameFrame++
+    
     handleInput()
-    updateAmmo()
+    for i := 0; i < MAX_PLAYERS; i++ {
+        updateAmmo(i)
+        updatePowerups(i)
+    }
     updateSpeeds()
-    updatePlayer()
-    updateBullets()
-    updateEnemyBullets()
-    updateEnemies()
-    updateObstacles()
-    updateParticles()
+    for i := 0; i < MAX_PLAYERS; i++ {
+        updatePlayer(i)
+    }
+    updateEntities()
     checkCollisions()
+    updateBoss()
     updateCamera()
-    proceduralSpawn()
+    if !bossActive() {
+        proceduralSpawn()
+    }
     
-    if (player.flags & 0x02) == 0 { // not alive
+    // Fim de jogo só quando todos morrem
+    if leadPlayer() < 0 {
         enterGameOver()
-        if score > highScore {
-            highScore = score
-        }
     }
 }
 
 // Responsável pelo funcionamento da munição
-func updateAmmo() {
-    if isReloading {
-        reloadTimer++
-        if reloadTimer >= RELOAD_TIME {
-            ammo = MAX_AMMO
-            isReloading = false
-            reloadTimer = 0
+func updateAmmo(p int) {
+    pl := &players[p]
+    if (pl.flags & 0x02) == 0 { // not alive
+        return
+    }
+    if pl.isReloading {
+        pl.reloadTimer++
+        if pl.reloadTimer >= reloadTime(p) {
+            pl.ammo = maxAmmo(p)
+            pl.isReloading = false
+            pl.reloadTimer = 0
+            playSfx(SFX_RELOAD_DONE)
         }
-    } else if ammo == 0 {
+    } else if pl.ammo == 0 {
         // Inicia recarga automática quando não há mais munição
-        isReloading = true
-        reloadTimer = 0
+        pl.isReloading = true
+        pl.reloadTimer = 0
     }
 }
 
 func enterGameOver() {
     gameState = STATE_GAME_OVER
     gameOverTimer = 120 // 2 segundos
-    previousGamepadState = *GAMEPAD1 // Captura o estado atual dos botões
+    previousGamepadState = input.Gamepad(0) // Captura o estado atual dos botões
+
+    // Partidas reproduzidas não contam para o perfil
+    if replayMode == REPLAY_PLAYING {
+        finishPlayback()
+        return
+    }
+    finishRecording()
+    recordRun()
 }
 
 func updateGameOver() {
-    gamepad := *GAMEPAD1
+    gamepad := input.Gamepad(0)
     
     // Primeiro, aguarda o timer E que os botões sejam soltos
     if gameOverTimer > 0 {
@@ -549,6 +483,7 @@ func updateGameOver() {
     
     if buttonJustPressed {
         gameState = STATE_MENU
+        menuPrevGamepad = gamepad
         resetGame()
     }
     
@@ -556,405 +491,423 @@ func updateGameOver() {
 }
 
 func startGame() {
-    resetGame()
-    player.flags = 0x03
-    gameFrame = 0
-    score = 0
-    cameraX = 0
-    
-    // Reset do sistema de munição
-    ammo = MAX_AMMO
-    reloadTimer = 0
-    isReloading = false
-
-    // Reset da direção da mira
-    aimDirection = AIM_HORIZONTAL
+    challengeRun = false
 
     // Seed baseado no tempo real absoluto (frameCounter nunca reseta)
     gameStartRealTime = frameCounter
-    rngSeed = uint32(gameStartRealTime*31337 + (gameStartRealTime<<7) + (gameStartRealTime>>3))
+    runSeed = mix32(uint32(gameStartRealTime))
 
-    // Mistura com outros fatores únicos do momento
-    rngSeed = rngSeed ^ uint32(gameStartRealTime*gameStartRealTime)
-    rngSeed = rngSeed ^ uint32((gameStartRealTime%997) * 2039)
-    rngSeed = rngSeed ^ uint32((gameStartRealTime%1009) * 4093)
+    startRecording(runSeed)
+    beginRun(runSeed)
+}
 
-    // "Aquece" o gerador com base no tempo
-    warmupCount := (gameStartRealTime % 50) + 10
-    for i := 0; i < int(warmupCount); i++ {
-        rng()
-    }
-    
-    // Reset do sistema procedural com valores mais variados
-    spawnTimer = randInt(20) // Começa em momento aleatório
-    nextSpawnDelay = 30 + randInt(40) // Delay inicial mais variado
-    lastSpawnType = -1
-    lastSpawnX = 0
-    currentPattern = int8(randInt(4)) // Padrão inicial aleatório
-    patternTimer = randInt(400) // Tempo de padrão inicial muito variado
+// Prepara uma partida a partir de uma seed.
+// Tudo que influencia a simulação precisa ser reiniciado aqui para o replay
+// reproduzir a partida exatamente.
+func beginRun(seed uint32) {
+    resetGame() // Jogadores, munição e mira
+    gameFrame = 0
+    score = 0
+    runKills = 0
+    cameraX = 0
+    resetBoss()
+
+    // Reset das velocidades
+    updateSpeeds()
+    syncPauseInput()
+
+    runSeed = seed
+    seedStreams(seed)
+    resetTerrain()
 }
 
 func resetGame() {
-    initPlayer()
-    clearArrays()
+    initPlayers()
+    clearEntities()
 }
 
 func handleInput() {
-    gamepad := *GAMEPAD1
-    mouseButtons := *MOUSE_BUTTONS
+    // Entrada ao vivo ou do replay
+    readFrameInput()
+    
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if !players[i].joined {
+            continue
+        }
+        gamepad := frameGamepads[i]
+        mouseButtons := uint8(0)
+        if i == 0 {
+            mouseButtons = frameMouseButtons // O mouse é sempre do jogador local
+        }
+        if (players[i].flags & 0x02) != 0 { // alive
+            handlePlayerInput(i, gamepad, mouseButtons)
+        }
+        
+        // Atualizar estados anteriores
+        players[i].prevGamepad = gamepad
+        players[i].prevMouseButtons = mouseButtons
+    }
+}
+
+func handlePlayerInput(p int, gamepad, mouseButtons uint8) {
+    pl := &players[p]
     
     // Detectar pressionamento (não repetição)
-    gamepadPressed := gamepad & ^prevGamepad
-    mousePressed := mouseButtons & ^prevMouseButtons
+    gamepadPressed := gamepad & ^pl.prevGamepad
+    mousePressed := mouseButtons & ^pl.prevMouseButtons
     
-    // Controle da mira - direcionais do gamepad
-    if gamepad&BUTTON_RIGHT != 0 {
-        aimDirection = AIM_HORIZONTAL
-    }
-    if gamepad&BUTTON_UP != 0 {
-        aimDirection = AIM_VERTICAL
+    // Controle da mira - direcionais do gamepad; a mira fica na última
+    // direção apertada
+    if aim := padAim(gamepad, (pl.flags&0x01) != 0); aim >= 0 {
+        pl.aimDirection = aim
     }
     
-    // Pulo - BUTTON_1 (X, V, espaço ou botão esquerdo do mouse)
-    if (gamepadPressed&BUTTON_1 != 0 || mousePressed&MOUSE_LEFT != 0) && 
-       (player.flags&0x01) != 0 { // onGround
-        player.velY = currentJumpPower
-        player.flags &= 0xFE // clear onGround
+    // Pulo - BUTTON_1 (X, V, espaço ou botão esquerdo do mouse). O toque
+    // fica guardado por alguns quadros e vale um pouco depois de sair do chão
+    if gamepadPressed&BUTTON_1 != 0 || mousePressed&MOUSE_LEFT != 0 {
+        pl.jumpBuffer = PLAYER_JUMP_BUFFER_FRAMES
+    }
+    if pl.jumpBuffer > 0 {
+        if canJump(&pl.playerBody) {
+            startJump(&pl.playerBody)
+            pl.jumpBuffer = 0
+            playSfx(SFX_JUMP)
+        } else {
+            pl.jumpBuffer--
+        }
     }
-    
-    // Tiro - BUTTON_2 (Z, C ou botão direito do mouse)
-    if (gamepadPressed&BUTTON_2 != 0 || mousePressed&MOUSE_RIGHT != 0){
-        shoot()
+    if gamepad&BUTTON_1 == 0 && mouseButtons&MOUSE_LEFT == 0 {
+        releaseJump(&pl.playerBody)
     }
     
-    // Atualizar estados anteriores
-    prevGamepad = gamepad
-    prevMouseButtons = mouseButtons
+    // Tiro - BUTTON_2 (Z ou C) na mira; botão direito do mouse no cursor
+    if gamepadPressed&BUTTON_2 != 0 {
+        shoot(p)
+    } else if mousePressed&MOUSE_RIGHT != 0 {
+        shootAt(p, int32(frameMouseX)+cameraX, int32(frameMouseY))
+    }
 }
 
 // Responsável pela atualização do estado do jogador
-func updatePlayer() {
-    if (player.flags & 0x02) == 0 { // not alive
+func updatePlayer(p int) {
+    pl := &players[p]
+    if (pl.flags & 0x02) == 0 { // not alive
         return
     }
     
-    // Movimento horizontal - mais rápido no ar para facilitar pulos
-    if (player.flags & 0x01) == 0 { // not onGround (está no ar)
-        player.x += currentPlayerSpeed
-    } else {
-        player.x += currentPlayerSpeed
+    landed := moveBody(&pl.playerBody)
+    // Na luta contra o chefe a câmera fica parada e o jogador também
+    if bossActive() && pl.x > cameraX+BOSS_PLAYER_MAX_X {
+        pl.x = cameraX + BOSS_PLAYER_MAX_X
     }
     
-    // Gravidade
-    if (player.flags & 0x01) == 0 { // not onGround
-        player.velY += 1
-        if player.velY > 8 {
-            player.velY = 8
-        }
-        player.y += int32(player.velY)
-        
-        if player.y >= GROUND_Y-PLAYER_HEIGHT {
-            player.y = GROUND_Y - PLAYER_HEIGHT
-            player.velY = 0
-            player.flags |= 0x01 // set onGround
+    // No chão não se mira para baixo
+    if landed {
+        switch pl.aimDirection {
+        case AIM_DOWN, AIM_DOWN_RIGHT:
+            pl.aimDirection = AIM_RIGHT
+        case AIM_DOWN_LEFT:
+            pl.aimDirection = AIM_LEFT
         }
     }
     
+    // Caiu no buraco
+    if pl.y > SCREEN_HEIGHT {
+        killPlayer(p)
+        return
+    }
+    
+    if pl.invuln > 0 {
+        pl.invuln--
+    }
+    
     // Animação de corrida
-    player.animFrame++
-    if player.animFrame > 20 {
-        player.animFrame = 0
+    pl.animFrame++
+    if pl.animFrame > 20 {
+        pl.animFrame = 0
     }
 }
 
-// Responsável pela mecânica de tiro
-func updateBullets() {
-    for i := 0; i < MAX_BULLETS; i++ {
-        if bullets[i].active {
-            bullets[i].x += int32(bullets[i].velX)
-            bullets[i].y += int32(bullets[i].velY)
-            
-            // Remove se sair da tela em qualquer direção
-            if bullets[i].x < cameraX-20 || bullets[i].x > cameraX+SCREEN_WIDTH+20 ||
-               bullets[i].y < -20 || bullets[i].y > SCREEN_HEIGHT+20 {
-                bullets[i].active = false
-            }
+// Física de um quadro: corre para a direita contra as paredes do terreno,
+// acompanha o chão e cai. Devolve true no quadro em que pousa
+func moveBody(b *playerBody) bool {
+    // Movimento horizontal: acelera (ou freia) até a velocidade da dificuldade
+    if b.velX < currentPlayerSpeed {
+        b.velX = min16(b.velX+PLAYER_ACCEL, currentPlayerSpeed)
+    } else if b.velX > currentPlayerSpeed {
+        b.velX = max16(b.velX-PLAYER_ACCEL, currentPlayerSpeed)
+    }
+    integrate(&b.x, &b.subX, b.velX)
+    // Degrau alto à frente: fica encostado
+    if x, blocked := terrainWall(b.x, PLAYER_WIDTH, b.y+PLAYER_HEIGHT); blocked {
+        b.x = x
+        b.subX = 0
+    }
+    
+    // No chão, acompanha rampas e degraus pequenos; sem nada embaixo, cai
+    if (b.flags & 0x01) != 0 { // onGround
+        feet := b.y + PLAYER_HEIGHT
+        if floor, ok := terrainFloor(b.x, PLAYER_WIDTH, feet-TERRAIN_STEP, feet+TERRAIN_STEP); ok {
+            b.y = floor - PLAYER_HEIGHT
+            b.coyote = PLAYER_COYOTE_FRAMES
+            return false
         }
+        b.flags &= 0xFE // clear onGround
     }
-}
-
-// Responsável pela mecânica de tiro inimigo
-func updateEnemyBullets() {
-    for i := 0; i < MAX_ENEMY_BULLETS; i++ {
-        if enemyBullets[i].active {
-            enemyBullets[i].x += int32(enemyBullets[i].velX)
-            enemyBullets[i].y += int32(enemyBullets[i].velY)
-            
-            // Remove se sair da tela
-            if enemyBullets[i].x < cameraX-20 || enemyBullets[i].x > cameraX+SCREEN_WIDTH+20 ||
-               enemyBullets[i].y < 0 || enemyBullets[i].y > SCREEN_HEIGHT {
-                enemyBullets[i].active = false
-            }
+    
+    // Gravidade; segurando o pulo, a subida é mais leve por alguns quadros
+    if b.coyote > 0 {
+        b.coyote--
+    }
+    gravity := int16(PLAYER_GRAVITY)
+    if b.jumpHold > 0 {
+        b.jumpHold--
+        if b.velY < 0 {
+            gravity = PLAYER_HOLD_GRAVITY
         }
     }
+    b.velY = min16(b.velY+gravity, PLAYER_TERMINAL_VELOCITY)
+    feet := b.y + PLAYER_HEIGHT
+    integrate(&b.y, &b.subY, b.velY)
+    
+    // Pousa na superfície que os pés cruzaram caindo
+    floor, ok := terrainFloor(b.x, PLAYER_WIDTH, feet-TERRAIN_STEP, b.y+PLAYER_HEIGHT)
+    if ok && b.velY >= 0 {
+        b.y = floor - PLAYER_HEIGHT
+        b.subY = 0
+        b.velY = 0
+        b.flags |= 0x01 // set onGround
+        return true
+    }
+    return false
 }
 
-// Atualiza as características e estado dos inimigos
-func updateEnemies() {
-    for i := 0; i < MAX_ENEMIES; i++ {
-        if enemies[i].active {
-            enemies[i].animFrame++
-            
-            // Sistema de tiro dos inimigos
-            if enemies[i].animFrame%ENEMY_SHOOT_RATE == 0 {
-                shootEnemyBullet(enemies[i].x, enemies[i].y, enemies[i].enemyType)
-            }
+// No chão, ou logo depois de sair dele
+func canJump(b *playerBody) bool {
+    return (b.flags&0x01) != 0 || b.coyote > 0
+}
 
-            // Características dos inimigos terrestres
-            if enemies[i].enemyType == ENEMY_GROUND {
-                enemies[i].velX = -int8(currentEnemySpeed)
-                enemies[i].y = GROUND_Y - 12
-            } else if enemies[i].enemyType == ENEMY_FLYING {    // Características dos aéreos
-                enemies[i].velX = -int8(currentEnemySpeed)
-                // Movimento senoidal para voar
-                if (enemies[i].animFrame/15)%2 == 0 {
-                    enemies[i].velY = -1
-                } else {
-                    enemies[i].velY = 1
-                }
-                
-                if enemies[i].y < 40 {
-                    enemies[i].y = 40
-                    enemies[i].velY = 1
-                }
-                if enemies[i].y > GROUND_Y-30 {
-                    enemies[i].y = GROUND_Y - 30
-                    enemies[i].velY = -1
-                }
-            }
-            
-            enemies[i].x += int32(enemies[i].velX)
-            enemies[i].y += int32(enemies[i].velY)
-            
-            if enemies[i].x < cameraX-50 {
-                enemies[i].active = false
-            }
-        }
+func startJump(b *playerBody) {
+    b.velY = currentJumpPower
+    b.jumpHold = PLAYER_JUMP_HOLD_FRAMES
+    b.coyote = 0
+    b.flags &= 0xFE // clear onGround
+}
+
+// Soltou o botão: a subida volta à gravidade normal e é cortada
+func releaseJump(b *playerBody) {
+    b.jumpHold = 0
+    b.velY = max16(b.velY, -PLAYER_JUMP_CUT_VELOCITY)
+}
+
+// Pontos vão para o jogador e para o placar da equipe
+func addScore(p int, points int32) {
+    if hasPowerup(p, POWERUP_MULTIPLIER) {
+        points *= 2
     }
+    players[p].score += points
+    score += points
 }
 
-// Mecanismo de tiro inimigo
-func shootEnemyBullet(x, y int32, enemyType int8) {
-    for i := 0; i < MAX_ENEMY_BULLETS; i++ {
-        if !enemyBullets[i].active {
-            if enemyType == ENEMY_GROUND {
-                enemyBullets[i].x = x - 2
-                enemyBullets[i].y = y + 6
-                enemyBullets[i].velX = -2
-                enemyBullets[i].velY = 0
-            } else {
-                enemyBullets[i].x = x + 4
-                enemyBullets[i].y = y + 8
-                enemyBullets[i].velX = 0
-                enemyBullets[i].velY = 1
-            }
-            
-            enemyBullets[i].active = true
-            return
-        }
+// Jogador atingido: tira vida; sem vida ele morre, senão fica invencível
+// por um tempo
+func hurtPlayer(p int, damage int8) {
+    pl := &players[p]
+    if (pl.flags & 0x02) == 0 || pl.invuln > 0 {
+        return
+    }
+    // O escudo fica com o acerto inteiro, até o do espeto
+    if pl.powerups[POWERUP_SHIELD] > 0 {
+        pl.powerups[POWERUP_SHIELD] = 0
+        pl.invuln = PLAYER_INVULN_FRAMES
+        playSfx(SFX_SHIELD_BLOCK)
+        return
     }
+    pl.hp -= damage
+    if pl.hp <= 0 {
+        pl.hp = 0
+        killPlayer(p)
+        return
+    }
+    pl.invuln = PLAYER_INVULN_FRAMES
+    playSfx(SFX_PLAYER_HURT)
 }
 
-// Responsável pelos obstáculos
-func updateObstacles() {
-    for i := 0; i < MAX_OBSTACLES; i++ {
-        if obstacles[i].active {
-            if obstacles[i].x < cameraX-50 {
-                obstacles[i].active = false
-            }
-        }
+// Morte do jogador, por dano ou por cair num buraco
+func killPlayer(p int) {
+    pl := &players[p]
+    if (pl.flags & 0x02) == 0 { // já morreu neste quadro
+        return
     }
+    pl.flags &= 0xFD // clear alive
+    createExplosion(pl.x, pl.y)
+    playSfx(SFX_PLAYER_DEATH)
 }
 
-// Responsável pelas partículas
-func updateParticles() {
-    for i := 0; i < MAX_PARTICLES; i++ {
-        if particles[i].active {
-            particles[i].x += int32(particles[i].velX)
-            particles[i].y += int32(particles[i].velY)
-            particles[i].velY += 1
-            particles[i].life--
-            
-            if particles[i].life <= 0 {
-                particles[i].active = false
-            }
-        }
+// Atualiza a camera - segue o jogador mais à frente sem voltar, deixando
+// espaço na tela para a fila de jogadores. Trava durante a luta com o chefe
+func updateCamera() {
+    lead := leadPlayer()
+    if lead < 0 || bossActive() {
+        return
+    }
+    target := players[lead].x - SCREEN_WIDTH/8 - PLAYER_SPACING*(joinedCount()-1)
+    if target > cameraX {
+        cameraX = target
     }
 }
 
-// Verifica as diversas colisões possíveis
-func checkCollisions() {
-    // Tiro vs inimigo
-    for i := 0; i < MAX_BULLETS; i++ {
-        if bullets[i].active {
-            for j := 0; j < MAX_ENEMIES; j++ {
-                if enemies[j].active {
-                    var enemyW, enemyH int32 = 8, 12
-                    if enemies[j].enemyType == ENEMY_FLYING {
-                        enemyH = 8
-                    }
-                    
-                    if collision(bullets[i].x, bullets[i].y, BULLET_WIDTH, BULLET_HEIGHT,
-                                enemies[j].x, enemies[j].y, enemyW, enemyH) {
-                        bullets[i].active = false
-                        enemies[j].active = false
-                        score += 10
-                        createExplosion(enemies[j].x, enemies[j].y)
-                    }
-                }
-            }
-        }
+// Auxiliar da check colision
+func collision(x1, y1, w1, h1, x2, y2, w2, h2 int32) bool {
+    return x1 < x2+w2 && x1+w1 > x2 && y1 < y2+h2 && y1+h1 > y2
+}
+
+// Mecanismo de tiro do jogador, na direção da mira
+func shoot(p int) {
+    aim := players[p].aimDirection
+    fire(p, aimVelocities[aim][0], aimVelocities[aim][1])
+}
+
+// Dispara da boca do cano com a velocidade dada
+func fire(p int, velX, velY int16) {
+    pl := &players[p]
+    if pl.ammo <= 0 || pl.isReloading {
+        playSfx(SFX_EMPTY_CLICK)
+        return // Não pode atirar se não tem munição ou está recarregando
     }
     
-    // Tiro vs tiro inimigo
-    for i := 0; i < MAX_BULLETS; i++ {
-        if bullets[i].active {
-            for j := 0; j < MAX_ENEMY_BULLETS; j++ {
-                if enemyBullets[j].active {
-                    if collision(bullets[i].x-1, bullets[i].y-1, BULLET_WIDTH+2, BULLET_HEIGHT+2,
-                                enemyBullets[j].x-1, enemyBullets[j].y-1, ENEMY_BULLET_WIDTH+2, ENEMY_BULLET_HEIGHT+2) {
-                        bullets[i].active = false
-                        enemyBullets[j].active = false
-                        createExplosion(bullets[i].x, bullets[i].y)
-                        score += 5 // Bônus por interceptar bala inimiga
-                    }
-                }
-            }
+    // Cada jogador tem no máximo MAX_BULLETS tiros na tela
+    inFlight := 0
+    for i := 0; i < MAX_ENTITIES; i++ {
+        if entities[i].active && entities[i].kind == KIND_BULLET && int(entities[i].owner) == p {
+            inFlight++
         }
     }
-    
-    // Jogador vs tiro inimigo
-    for i := 0; i < MAX_ENEMY_BULLETS; i++ {
-        if enemyBullets[i].active {
-            if collision(player.x, player.y, PLAYER_WIDTH, PLAYER_HEIGHT,
-                        enemyBullets[i].x, enemyBullets[i].y, ENEMY_BULLET_WIDTH, ENEMY_BULLET_HEIGHT) {
-                enemyBullets[i].active = false
-                player.flags &= 0xFD // clear alive
-                createExplosion(player.x, player.y)
-            }
-        }
+    if inFlight >= MAX_BULLETS {
+        return
     }
     
-    // Jogador vs inimigos
-    for i := 0; i < MAX_ENEMIES; i++ {
-        if enemies[i].active {
-            var enemyW, enemyH int32 = 8, 12
-            if enemies[i].enemyType == ENEMY_FLYING {
-                enemyH = 8
-            }
-            
-            if collision(player.x, player.y, PLAYER_WIDTH, PLAYER_HEIGHT,
-                        enemies[i].x, enemies[i].y, enemyW, enemyH) {
-                player.flags &= 0xFD // clear alive
-                createExplosion(player.x, player.y)
-            }
-        }
+    x, y := pl.x+aimMuzzles[pl.aimDirection][0], pl.y+aimMuzzles[pl.aimDirection][1]
+    if spawnBullet(p, x, y, velX, velY) < 0 {
+        return
     }
     
-    // Jogador vs obstáculos
-    for i := 0; i < MAX_OBSTACLES; i++ {
-        if obstacles[i].active {
-            if collision(player.x+1, player.y+2, PLAYER_WIDTH-2, PLAYER_HEIGHT-2,
-                        obstacles[i].x, obstacles[i].y, int32(obstacles[i].width), int32(obstacles[i].height)) {
-                player.flags &= 0xFD // clear alive
-                createExplosion(player.x, player.y)
-            }
-        }
+    // Leque: mais dois tiros abrindo para os lados, sem gastar munição
+    if hasPowerup(p, POWERUP_SPREAD) {
+        perpX, perpY := -sign16(velY)*FIXED_ONE, sign16(velX)*FIXED_ONE
+        spawnBullet(p, x, y, velX+perpX, velY+perpY)
+        spawnBullet(p, x, y, velX-perpX, velY-perpY)
+    }
+    pl.ammo-- // Consome munição
+    playSfx(SFX_SHOOT)
+}
+
+// Tiro do jogador com a caixa de colisão virada para onde ele vai
+func spawnBullet(p int, x, y int32, velX, velY int16) int {
+    slot := spawnEntity(KIND_BULLET, x, y, velX, velY)
+    if slot < 0 {
+        return -1
     }
+    e := &entities[slot]
+    e.owner = int8(p)
+    e.width, e.height = bulletSize(velX, velY)
+    return slot
 }
 
-// Atualiza a camera
-func updateCamera() {
-    cameraX = player.x - SCREEN_WIDTH/8
-    if cameraX < 0 {
-        cameraX = 0
+// Deitado, em pé ou quadrado na diagonal, conforme o eixo dominante
+func bulletSize(velX, velY int16) (int8, int8) {
+    absX, absY := velX*sign16(velX), velY*sign16(velY)
+    if absX > 2*absY {
+        return BULLET_WIDTH, BULLET_HEIGHT
+    }
+    if absY > 2*absX {
+        return BULLET_HEIGHT, BULLET_WIDTH
     }
+    return BULLET_DIAGONAL, BULLET_DIAGONAL
 }
 
-// Auxiliar da check colision
-func collision(x1, y1, w1, h1, x2, y2, w2, h2 int32) bool {
-    return x1 < x2+w2 && x1+w1 > x2 && y1 < y2+h2 && y1+h1 > y2
+func min16(a, b int16) int16 {
+    if a < b {
+        return a
+    }
+    return b
 }
 
-// Mecanismo de tiro do jogador
-func shoot() {
-    if ammo <= 0 || isReloading {
-        return // Não pode atirar se não tem munição ou está recarregando
+func max16(a, b int16) int16 {
+    if a > b {
+        return a
     }
-    
-    for i := 0; i < MAX_BULLETS; i++ {
-        if !bullets[i].active {
-            if aimDirection == AIM_HORIZONTAL {
-                // Tiro horizontal
-                bullets[i].x = player.x + PLAYER_WIDTH
-                bullets[i].y = player.y + 4
-                bullets[i].velX = 5
-                bullets[i].velY = 0
-            } else {
-                // Tiro vertical
-                bullets[i].x = player.x + 4 // centralizado no player
-                bullets[i].y = player.y - 2 // um pouco acima
-                bullets[i].velX = 0
-                bullets[i].velY = -5 // velocidade para cima
-            }
-            bullets[i].active = true
-            ammo-- // Consome munição
-            return
-        }
+    return b
+}
+
+func min32(a, b int32) int32 {
+    if a < b {
+        return a
     }
+    return b
 }
 
-// Geração de inimigos
-func spawnEnemy(x, y int32, enemyType int8) {
-    for i := 0; i < MAX_ENEMIES; i++ {
-        if !enemies[i].active {
-            enemies[i].x = x
-            enemies[i].y = y
-            enemies[i].enemyType = enemyType
-            enemies[i].active = true
-            enemies[i].animFrame = 0
-            return
-        }
+func max32(a, b int32) int32 {
+    if a > b {
+        return a
     }
+    return b
 }
 
-// Geração de obstáculos
-func spawnObstacle(x, y int32, width, height int8, obstacleType int8) {
-    for i := 0; i < MAX_OBSTACLES; i++ {
-        if !obstacles[i].active {
-            obstacles[i].x = x
-            obstacles[i].y = y
-            obstacles[i].width = width
-            obstacles[i].height = height
-            obstacles[i].obstacleType = obstacleType
-            obstacles[i].active = true
-            return
-        }
+func sign16(v int16) int16 {
+    if v > 0 {
+        return 1
+    }
+    if v < 0 {
+        return -1
+    }
+    return 0
+}
+
+// Direção pedida no direcional, ou -1 se nenhuma; para baixo só no ar
+func padAim(gamepad uint8, onGround bool) int8 {
+    dx, dy := 0, 0
+    if gamepad&BUTTON_RIGHT != 0 {
+        dx++
+    }
+    if gamepad&BUTTON_LEFT != 0 {
+        dx--
+    }
+    if gamepad&BUTTON_UP != 0 {
+        dy--
+    }
+    if gamepad&BUTTON_DOWN != 0 && !onGround {
+        dy++
     }
+    return aimFromPad[dy+1][dx+1]
 }
 
+// Geração de inimigos (KIND_GROUND_ENEMY ou KIND_FLYING_ENEMY)
+func spawnEnemy(x, y int32, kind int8) {
+    spawnEntity(kind, x, y, 0, 0)
+}
+
+// Geração de obstáculos (KIND_ROCK ou KIND_SPIKE)
+func spawnObstacle(x, y int32, width, height int8, kind int8) {
+    if slot := spawnEntity(kind, x, y, 0, 0); slot >= 0 {
+        entities[slot].width = width
+        entities[slot].height = height
+    }
+}
+
+// Uma partícula por explosão, espalhada conforme as que já estão no ar; o
+// desvio para os lados vem do stream de efeitos (rand.go)
 func createExplosion(x, y int32) {
-    for i := 0; i < MAX_PARTICLES; i++ {
-        if !particles[i].active {
-            particles[i].x = x + int32(i*2)
-            particles[i].y = y + int32(i)
-            particles[i].velX = int8((i%3 - 1) * 2)
-            particles[i].velY = int8(-2 - i/2)
-            particles[i].life = 25
-            particles[i].active = true
-            return
-        }
+    slot := spawnEntity(KIND_PARTICLE, x, y, 0, 0)
+    if slot < 0 {
+        return
     }
+    n := int32(countTeam(TEAM_FX) - 1)
+    e := &entities[slot]
+    e.x += n * 2
+    e.y += n
+    e.velX = int16(cosmeticRand.intn(5)-2) * FIXED_ONE
+    e.velY = int16((-2 - n/2) * FIXED_ONE)
 }
 
 func draw() {
@@ -965,513 +918,272 @@ func draw() {
         drawGame()
     case STATE_GAME_OVER:
         drawGameOver()
+    case STATE_PAUSED:
+        drawGame()
+        drawPause()
     }
 }
 
 func drawMenu() {
-    *DRAW_COLORS = 0x01
+    setColors(0x01)
     rect(0, 0, SCREEN_WIDTH, SCREEN_HEIGHT)
     
-    *DRAW_COLORS = 0x03
-    drawSimpleText("JUMP 'N' SHOOT", 40, 30)
+    setColors(0x03)
+    drawTextCentered("JUMP 'N' SHOOT", SCREEN_WIDTH/2, 30)
     
-    *DRAW_COLORS = 0x04
-    drawSimpleText("PRESS ANY BUTTON", 35, 60)
+    if codeEditing {
+        drawCodeEditor()
+        return
+    }
     
-    *DRAW_COLORS = 0x04
+    setColors(0x04)
+    drawTextCentered("PRESS ANY BUTTON", SCREEN_WIDTH/2, 60)
+    if replayAvailable() && replayPartial() {
+        drawTextCentered("DOWN: PARTIAL REPLAY", SCREEN_WIDTH/2, 70)
+    } else if replayAvailable() {
+        drawTextCentered("DOWN: REPLAY", SCREEN_WIDTH/2, 70)
+    }
+    
+    // Jogadores na partida
+    for i := 0; i < MAX_PLAYERS; i++ {
+        setColors(0x02)
+        if players[i].joined {
+            setColors(playerColors[i])
+        }
+        drawSimpleText("P", 38+int32(i)*24, 45)
+        drawDigit(i+1, 44+int32(i)*24, 45)
+    }
+    if input.Netplay()&NETPLAY_ACTIVE != 0 {
+        setColors(0x03)
+        x := SCREEN_WIDTH/2 - textWidth("NETPLAY P0")/2
+        drawSimpleText("NETPLAY P", x, 80)
+        drawDigit(int(input.Netplay()&0x03)+1, x+textWidth("NETPLAY P")+FONT_SPACING, 80)
+    }
+    
+    setColors(0x04)
     drawSimpleText("HIGH:", 60, 90)
     drawNumber(highScore, 100, 90)
-
-    *DRAW_COLORS = 0x03
+    drawSimpleText("GAMES:", 60, 100)
+    drawNumber(int32(gamesPlayed), 100, 100)
+    setColors(0x03)
+    drawTextCentered("RIGHT: CHALLENGE CODE", SCREEN_WIDTH/2, 110)
     drawSimpleText("JUMP:X,V,SPACE,MOUSE(LEFT)", 5, 120)
     drawSimpleText("SHOOT:Z,C,MOUSE(RIGHT)", 5, 130)
 }
 
 func drawGame() {
     // Céu
-    *DRAW_COLORS = 0x01
+    setColors(0x01)
     rect(0, 0, SCREEN_WIDTH, GROUND_Y-20)
     
-    *DRAW_COLORS = 0x10
+    setColors(0x10)
     rect(0, GROUND_Y-20, SCREEN_WIDTH, 20)
     
     // Chão
-    *DRAW_COLORS = 0x02
-    rect(0, GROUND_Y, SCREEN_WIDTH, SCREEN_HEIGHT-GROUND_Y)
+    drawTerrain()
     
-    drawPlayer()
-    drawBullets()
-    drawEnemyBullets()
-    drawEnemies()
-    drawObstacles()
-    drawParticles()
+    for i := 0; i < MAX_PLAYERS; i++ {
+        drawPlayer(i)
+    }
+    drawEntities()
+    drawBossBar()
     drawUI()
+    drawCrosshair()
 }
 
 func drawGameOver() {
-    *DRAW_COLORS = 0x01
+    setColors(0x01)
     rect(0, 0, SCREEN_WIDTH, SCREEN_HEIGHT)
     
-    *DRAW_COLORS = 0x03
-    drawSimpleText("GAME OVER", 50, 40)
+    setColors(0x03)
+    drawTextCentered("GAME OVER", SCREEN_WIDTH/2, 40)
     drawSimpleText("SCORE:", 50, 80)
-    drawNumber(score, 110, 80)
-    drawSimpleText("PRESS ANY BUTTON", 35, 120)
+    drawNumber(score, 90, 80)
+    
+    // Pontos de cada jogador no co-op
+    if joinedCount() > 1 {
+        for i := 0; i < MAX_PLAYERS; i++ {
+            if players[i].joined {
+                x := 20 + int32(i%2)*70
+                y := 92 + int32(i/2)*10
+                setColors(playerColors[i])
+                drawSimpleText("P", x, y)
+                drawDigit(i+1, x+6, y)
+                drawSimpleText(":", x+12, y)
+                drawNumberRight(players[i].score, x+50, y)
+            }
+        }
+        setColors(0x03)
+    }
+    
+    // Resultado da reprodução
+    if playbackDone {
+        if playbackMatched && replayPartial() {
+            drawTextCentered("PARTIAL REPLAY OK", SCREEN_WIDTH/2, 60)
+        } else if playbackMatched {
+            drawTextCentered("REPLAY OK", SCREEN_WIDTH/2, 60)
+        } else {
+            drawTextCentered("REPLAY DESYNC", SCREEN_WIDTH/2, 60)
+        }
+    } else if replayTruncated {
+        drawTextCentered("PARTIAL REPLAY SAVED", SCREEN_WIDTH/2, 60)
+    }
+    // Desafio: o código para repassar e o melhor resultado nele
+    if challengeRun {
+        drawRunCode(108)
+        drawSimpleText("BEST:", 50, 68)
+        drawNumber(challengeBest(runCode), 90, 68)
+    }
+    drawTextCentered("PRESS ANY BUTTON", SCREEN_WIDTH/2, 120)
 }
 
-func drawPlayer() {
-    if (player.flags & 0x03) == 0 { // not alive
+func drawPlayer(p int) {
+    pl := &players[p]
+    if (pl.flags & 0x02) == 0 { // not alive
+        return
+    }
+    // Pisca enquanto está invencível
+    if pl.invuln > 0 && (pl.invuln/4)%2 == 1 {
         return
     }
     
-    screenX := player.x - cameraX
+    screenX := pl.x - cameraX
     if screenX < -PLAYER_WIDTH || screenX > SCREEN_WIDTH {
         return
     }
     
     // Selecionar frame de animação
     frame := 0
-    if player.animFrame > 10 {
+    if pl.animFrame > 10 {
         frame = 1
     }
     
+    // Bolha do escudo por trás do jogador
+    if hasPowerup(p, POWERUP_SHIELD) {
+        setColors(0x40)
+        rect(screenX-2, pl.y-2, PLAYER_WIDTH+4, PLAYER_HEIGHT+4)
+    }
+    
     // Desenhar player
-    drawSprite8x12(playerSprite[frame][:], screenX, player.y, 0x03)
+    drawSprite(SPRITE_PLAYER, frame, screenX, pl.y, playerColors[p], 0)
     
-    // Desenhar arma na posição adequada
-    if aimDirection == AIM_HORIZONTAL {
-        drawWeapon(screenX + 8, player.y + 4)
-    } else {
-        drawWeapon(screenX + 3, player.y + 4) // mais centralizado para arma vertical
+    // Número do jogador no co-op
+    if joinedCount() > 1 {
+        drawDigit(p+1, screenX+3, pl.y-8)
     }
-}
-
-func drawBulletIcon(x, y int32) {
-    *DRAW_COLORS = 0x04
-    for row := 0; row < 2; row++ {
-        data := bulletSprite[row]
-        for col := 0; col < 4; col++ {
-            if (data & (1 << (7 - col))) != 0 {
-                rect(x+int32(col), y+int32(row), 1, 1)
-            }
-        }
-    }
-}
-
-func drawWeapon(x, y int32) {
-    *DRAW_COLORS = 0x3
     
-    if aimDirection == AIM_HORIZONTAL {
-        // Arma horizontal
-        for row := 0; row < 3; row++ {
-            data := weaponSprite[row]
-            for col := 0; col < 6; col++ {
-                if (data & (1 << (7 - col))) != 0 {
-                    rect(x+int32(col), y+int32(row), 1, 1)
-                }
-            }
-        }
-    } else {
-        // Arma vertical
-        for row := 0; row < 6; row++ {
-            data := weaponVerticalSprite[row]
-            for col := 0; col < 3; col++ {
-                if (data & (1 << (7 - col))) != 0 {
-                    rect(x+int32(col), y-6+int32(row), 1, 1) // ajusta posição para cima
-                }
-            }
-        }
-    }
-}
-
-func drawBullets() {
-    for i := 0; i < MAX_BULLETS; i++ {
-        if bullets[i].active {
-            screenX := bullets[i].x - cameraX
-            if screenX >= -10 && screenX < SCREEN_WIDTH+10 {
-                if bullets[i].velY != 0 {
-                    // Bala vertical
-                    *DRAW_COLORS = 0x04
-                    rect(screenX, bullets[i].y, BULLET_HEIGHT, BULLET_WIDTH) // invertido
-                    *DRAW_COLORS = 0x03
-                    rect(screenX, bullets[i].y, BULLET_HEIGHT, 2) // ponta
-                } else {
-                    // Bala horizontal
-                    *DRAW_COLORS = 0x04
-                    rect(screenX, bullets[i].y, BULLET_WIDTH, BULLET_HEIGHT)
-                    *DRAW_COLORS = 0x03
-                    rect(screenX + BULLET_WIDTH - 2, bullets[i].y, 2, BULLET_HEIGHT)
-                }
-            }
-        }
-    }
-}
-
-func drawEnemies() {
-    for i := 0; i < MAX_ENEMIES; i++ {
-        if enemies[i].active {
-            screenX := enemies[i].x - cameraX
-            if screenX >= -20 && screenX < SCREEN_WIDTH+20 {
-                if enemies[i].enemyType == ENEMY_GROUND {
-                    // Inimigo terrestre
-                    drawSprite8x12(groundEnemySprite[:], screenX, enemies[i].y, 0x03)
-                } else {
-                    // Inimigo voador
-                    drawSprite8x8(flyingEnemySprite[:], screenX, enemies[i].y, 0x03)
-                }
-            }
-        }
-    }
-}
-
-func drawEnemyBullets() {
-    for i := 0; i < MAX_ENEMY_BULLETS; i++ {
-        if enemyBullets[i].active {
-            screenX := enemyBullets[i].x - cameraX
-            if screenX >= -10 && screenX < SCREEN_WIDTH+10 {
-                // Balas dos inimigos
-                *DRAW_COLORS = 0x03
-                rect(screenX, enemyBullets[i].y, ENEMY_BULLET_WIDTH, ENEMY_BULLET_HEIGHT)
-                // Adicionar um pixel central mais brilhante para melhor visibilidade
-                *DRAW_COLORS = 0x04
-                rect(screenX+1, enemyBullets[i].y+1, 1, 1)
-            }
-        }
-    }
+    // Desenhar arma na posição adequada
+    drawWeapon(screenX, pl.y, weaponAim(p), playerColors[p])
 }
 
-func drawObstacles() {
-    for i := 0; i < MAX_OBSTACLES; i++ {
-        if obstacles[i].active {
-            screenX := obstacles[i].x - cameraX
-            if screenX >= -30 && screenX < SCREEN_WIDTH+30 {
-                if obstacles[i].obstacleType == OBSTACLE_ROCK {
-                    // Rocha
-                    drawSprite8x8(rockSprite[:], screenX, obstacles[i].y, 0x04)
-                } else {
-                    // Spike
-                    drawSprite6x8(spikeSprite[:], screenX, obstacles[i].y, 0x04)
-                }
-            }
-        }
-    }
+func drawBulletIcon(x, y int32) {
+    drawSprite(SPRITE_BULLET, 0, x, y, 0x04, 0)
 }
 
-func drawParticles() {
-    for i := 0; i < MAX_PARTICLES; i++ {
-        if particles[i].active {
-            screenX := particles[i].x - cameraX
-            if screenX >= 0 && screenX < SCREEN_WIDTH {
-                *DRAW_COLORS = 0x32
-                rect(screenX, particles[i].y, 2, 2)
-                *DRAW_COLORS = 0x03
-                rect(screenX, particles[i].y, 1, 1)
-            }
-        }
-    }
+// Arma do jogador em (x, y). Girada, a imagem de 6x3 passa a ter 3x6
+func drawWeapon(x, y int32, aimDirection int8, colors uint16) {
+    pose := &weaponPoses[aimDirection]
+    drawSprite(pose.sprite, 0, x+pose.dx, y+pose.dy, colors, pose.flags)
 }
 
 func drawUI() {
-    *DRAW_COLORS = 0x03
+    setColors(0x03)
     drawSimpleText("SCORE:", 5, 5)
     drawNumber(score, 45, 5)
     
-    // Indicador de direção da mira
-    *DRAW_COLORS = 0x04
-    if aimDirection == AIM_HORIZONTAL {
-        drawSimpleText("AIM: FORWARD", 80, 5)
-    } else {
-        drawSimpleText("AIM: UP", 80, 5)
+    if replayMode == REPLAY_PLAYING {
+        drawTextRight("REPLAY", SCREEN_WIDTH-5, 15)
     }
     
+    if joinedCount() > 1 {
+        drawCoopUI()
+        return
+    }
+    pl := &players[0]
+    
+    // Indicador de direção da mira
+    setColors(0x04)
+    name := aimNames[pl.aimDirection]
+    drawTextRight(name, SCREEN_WIDTH-5, 5)
+    drawTextRight("AIM:", SCREEN_WIDTH-5-textWidth(name)-4, 5)
+    
     // Indicador de munição
-    *DRAW_COLORS = 0x04
+    setColors(0x04)
     drawSimpleText("AMMO:", 5, 15)
     
-    if isReloading {
-        *DRAW_COLORS = 0x03
+    if pl.isReloading {
+        setColors(0x03)
         drawSimpleText("RELOAD", 45, 15)
         // Barra de progresso do reload
-        *DRAW_COLORS = 0x02
+        setColors(0x02)
         rect(45, 25, 60, 4)
-        *DRAW_COLORS = 0x04
-        progress := (reloadTimer * 60) / RELOAD_TIME
+        setColors(0x04)
+        progress := (pl.reloadTimer * 60) / reloadTime(0)
         rect(45, 25, progress, 4)
     } else {
         // Desenhar balas restantes
-        for i := 0; i < int(ammo); i++ {
+        for i := 0; i < int(pl.ammo); i++ {
             drawBulletIcon(45+int32(i*6), 15)
         }
     }
+    
+    drawHearts(0, 5, 25)
+    drawPowerups(0, 5, 35)
 }
 
-// Função para desenhar sprite 8x8
-func drawSprite8x8(sprite []uint8, x, y int32, colors uint16) {
-    *DRAW_COLORS = colors
-    for row := 0; row < 8; row++ {
-        data := sprite[row]
-        for col := 0; col < 8; col++ {
-            if (data & (1 << (7 - col))) != 0 {
-                rect(x+int32(col), y+int32(row), 1, 1)
-            }
+// Corações cheios e vazios de um jogador
+func drawHearts(p int, x, y int32) {
+    for i := int8(0); i < PLAYER_MAX_HP; i++ {
+        colors := uint16(0x02)
+        if i < players[p].hp {
+            colors = 0x03
         }
+        drawSprite(SPRITE_HEART, 0, x+int32(i)*(SPRITE_HEART_WIDTH+1), y, colors, 0)
     }
 }
 
-// Função para desenhar sprite 6x8
-func drawSprite6x8(sprite []uint8, x, y int32, colors uint16) {
-    *DRAW_COLORS = colors
-    for row := 0; row < 8; row++ {
-        data := sprite[row]
-        for col := 0; col < 6; col++ {
-            if (data & (1 << (7 - col))) != 0 {
-                rect(x+int32(col), y+int32(row), 1, 1)
-            }
+// Uma linha compacta de munição e vida por jogador
+func drawCoopUI() {
+    row := int32(0)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        pl := &players[i]
+        if !pl.joined {
+            continue
         }
-    }
-}
-
-// Função para desenhar sprite 8x12
-func drawSprite8x12(sprite []uint8, x, y int32, colors uint16) {
-    *DRAW_COLORS = colors
-    for row := 0; row < 12; row++ {
-        data := sprite[row]
-        for col := 0; col < 8; col++ {
-            if (data & (1 << (7 - col))) != 0 {
-                rect(x+int32(col), y+int32(row), 1, 1)
+        y := 15 + row*8
+        row++
+        
+        setColors(playerColors[i])
+        drawSimpleText("P", 5, y)
+        drawDigit(i+1, 11, y)
+        
+        if (pl.flags & 0x02) == 0 {
+            drawSimpleText("X", COOP_AMMO_X, y) // Fora da partida
+        } else if pl.isReloading {
+            setColors(0x02)
+            rect(COOP_AMMO_X, y+1, 30, 3)
+            setColors(0x04)
+            rect(COOP_AMMO_X, y+1, (pl.reloadTimer*30)/reloadTime(i), 3)
+        } else {
+            count := min32(pl.ammo, maxAmmo(i))
+            step := coopAmmoStep(count)
+            for j := int32(0); j < count; j++ {
+                drawBulletIcon(COOP_AMMO_X+j*step, y+1)
             }
         }
+        if (pl.flags & 0x02) != 0 {
+            drawHearts(i, COOP_HEARTS_X, y+1)
+            drawPowerups(i, 104, y)
+        }
     }
 }
 
-func drawSimpleText(text string, x, y int32) {
-    for i := 0; i < len(text); i++ {
-        drawSimpleChar(text[i], x+int32(i)*6, y)
-    }
-}
-
-func drawSimpleChar(char byte, x, y int32) {
-    switch char {
-    case 'A':
-        rect(x, y+1, 1, 4)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+2, 2, 1)
-        rect(x+3, y+1, 1, 4)
-    case 'B':
-        rect(x, y, 1, 5)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+2, 2, 1)
-        rect(x+1, y+4, 2, 1)
-        rect(x+3, y+1, 1, 1)
-        rect(x+3, y+3, 1, 1)
-    case 'C':
-        rect(x, y+1, 1, 3)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+4, 2, 1)
-    case 'D':
-        rect(x, y, 1, 5)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+4, 2, 1)
-        rect(x+3, y+1, 1, 3)
-    case 'E':
-        rect(x, y, 1, 5)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+2, 2, 1)
-        rect(x+1, y+4, 2, 1)
-    case 'F':
-        rect(x, y, 1, 5)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+2, 2, 1)
-    case 'G':
-        rect(x, y+1, 1, 3)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+4, 2, 1)
-        rect(x+2, y+2, 2, 1)
-        rect(x+3, y+2, 1, 2)
-    case 'H':
-        rect(x, y, 1, 5)
-        rect(x+1, y+2, 2, 1)
-        rect(x+3, y, 1, 5)
-    case 'I':
-        rect(x, y, 3, 1)
-        rect(x+1, y+1, 1, 3)
-        rect(x, y+4, 3, 1)
-    case 'J':
-        rect(x+2, y, 1, 4)
-        rect(x, y+4, 3, 1)
-        rect(x, y+2, 1, 2)
-    case 'K':
-        rect(x, y, 1, 5)
-        rect(x+1, y+2, 1, 1)
-        rect(x+2, y+1, 1, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x+3, y, 1, 1)
-        rect(x+3, y+4, 1, 1)
-    case 'L':
-        rect(x, y, 1, 5)
-        rect(x+1, y+4, 2, 1)
-    case 'M':
-        rect(x, y, 1, 5)
-        rect(x+1, y+1, 1, 1)
-        rect(x+2, y+2, 1, 1)
-        rect(x+3, y+1, 1, 1)
-        rect(x+4, y, 1, 5)
-    case 'N':
-        rect(x, y, 1, 5)
-        rect(x+1, y+1, 1, 1)
-        rect(x+2, y+2, 1, 1)
-        rect(x+3, y, 1, 5)
-    case 'O':
-        rect(x, y+1, 1, 3)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+4, 2, 1)
-        rect(x+3, y+1, 1, 3)
-    case 'P':
-        rect(x, y, 1, 5)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+2, 2, 1)
-        rect(x+3, y+1, 1, 1)
-    case 'Q':
-        rect(x, y+1, 1, 3)
-        rect(x+1, y, 2, 1)
-        rect(x+1, y+4, 2, 1)
-        rect(x+3, y+1, 1, 2)
-        rect(x+2, y+3, 1, 1)
-        rect(x+3, y+4, 1, 1)
-    case 'R':
-        rect(x, y, 1, 5)      
-        rect(x+1, y, 2, 1)    
-        rect(x+1, y+2, 2, 1)  
-        rect(x+3, y+1, 1, 1)  
-        rect(x+2, y+3, 1, 1) 
-        rect(x+3, y+4, 1, 1)  
-    case 'S':
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 'T':
-        rect(x, y, 3, 1)
-        rect(x+1, y+1, 1, 4)
-    case 'U':
-        rect(x, y, 1, 4)
-        rect(x+1, y+4, 2, 1)
-        rect(x+3, y, 1, 4)
-    case 'V':
-        rect(x, y, 1, 3)
-        rect(x+1, y+3, 1, 1)
-        rect(x+2, y+4, 1, 1)
-        rect(x+3, y+3, 1, 1)
-        rect(x+4, y, 1, 3)
-    case 'W':
-        rect(x, y, 1, 5)
-        rect(x+1, y+4, 1, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x+3, y+4, 1, 1)
-        rect(x+4, y, 1, 5)
-    case 'X':
-        rect(x, y, 1, 1)
-        rect(x+1, y+1, 1, 1)
-        rect(x+2, y+2, 1, 1)
-        rect(x+3, y+1, 1, 1)
-        rect(x+4, y, 1, 1)
-        rect(x, y+4, 1, 1)
-        rect(x+1, y+3, 1, 1)
-        rect(x+3, y+3, 1, 1)
-        rect(x+4, y+4, 1, 1)
-    case 'Y':
-        rect(x, y, 1, 1)
-        rect(x+1, y+1, 1, 1)
-        rect(x+2, y+2, 1, 3)
-        rect(x+3, y+1, 1, 1)
-        rect(x+4, y, 1, 1)
-    case 'Z':
-        rect(x, y, 4, 1)
-        rect(x+3, y+1, 1, 1)
-        rect(x+2, y+2, 1, 1)
-        rect(x+1, y+3, 1, 1)
-        rect(x, y+4, 4, 1)
-    case ' ':
-        // espaço em branco
-    case ':':
-        rect(x+1, y+1, 1, 1)
-        rect(x+1, y+3, 1, 1)
-    }
-}
-
-func drawNumber(num, x, y int32) {
-    if num == 0 {
-        drawDigit(0, x, y)
-        return
-    }
-    
-    if num >= 10 {
-        drawNumber(num/10, x-6, y)
-    }
-    drawDigit(int(num%10), x, y)
-}
-
-func drawDigit(digit int, x, y int32) {
-    switch digit {
-    case 0:
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 3)
-        rect(x+2, y+1, 1, 3)
-        rect(x, y+4, 3, 1)
-    case 1:
-        rect(x+1, y, 1, 5)
-    case 2:
-        rect(x, y, 3, 1)
-        rect(x+2, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 3:
-        rect(x, y, 3, 1)
-        rect(x+2, y+1, 1, 1)
-        rect(x+1, y+2, 2, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 4:
-        rect(x, y, 1, 3)
-        rect(x+2, y, 1, 5)
-        rect(x+1, y+2, 1, 1)
-    case 5:
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 6:
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x, y+3, 1, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 7:
-        rect(x, y, 3, 1)
-        rect(x+2, y+1, 1, 4)
-    case 8:
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 1)
-        rect(x+2, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x, y+3, 1, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
-    case 9:
-        rect(x, y, 3, 1)
-        rect(x, y+1, 1, 1)
-        rect(x+2, y+1, 1, 1)
-        rect(x, y+2, 3, 1)
-        rect(x+2, y+3, 1, 1)
-        rect(x, y+4, 3, 1)
+// Distância entre as balas de uma linha do co-op para count balas caberem
+// antes dos corações, com COOP_AMMO_GAP pixels de folga
+func coopAmmoStep(count int32) int32 {
+    step := int32(5)
+    if count > 1 {
+        step = min32(step, (COOP_HEARTS_X-COOP_AMMO_GAP-COOP_AMMO_X-SPRITE_BULLET_WIDTH)/(count-1))
     }
+    return step
 }
-
-//go:wasmimport env rect
-func rect(x, y, width, height int32)
-
-func main() {}
@@ -0,0 +1,1872 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+// Partida nova no backend em memória, com um jogador
+func setupGame(t *testing.T) {
+    t.Helper()
+    *host = memoryPlatform{}
+    for i := 1; i < MAX_PLAYERS; i++ {
+        players[i].joined = false
+    }
+    start()
+    gameState = STATE_PLAYING
+    challengeRun = false
+    beginRun(12345)
+}
+
+// Slots ativos do tipo, na ordem do pool
+func activeOfKind(kind int8) []int {
+    var slots []int
+    for i := 0; i < MAX_ENTITIES; i++ {
+        if entities[i].active && entities[i].kind == kind {
+            slots = append(slots, i)
+        }
+    }
+    return slots
+}
+
+func countActiveBullets() int {
+    return len(activeOfKind(KIND_BULLET))
+}
+
+func TestCollision(t *testing.T) {
+    tests := []struct {
+        name string
+        x1, y1, w1, h1, x2, y2, w2, h2 int32
+        want bool
+    }{
+        {"sobrepostos", 0, 0, 8, 8, 4, 4, 8, 8, true},
+        {"contido", 0, 0, 10, 10, 2, 2, 2, 2, true},
+        {"mesma caixa", 5, 5, 3, 3, 5, 5, 3, 3, true},
+        {"encostado à direita", 0, 0, 8, 8, 8, 0, 8, 8, false},
+        {"encostado embaixo", 0, 0, 8, 8, 0, 8, 8, 8, false},
+        {"encostado na quina", 0, 0, 8, 8, 8, 8, 8, 8, false},
+        {"um pixel de sobra", 0, 0, 8, 8, 7, 7, 8, 8, true},
+        {"separados", 0, 0, 4, 4, 10, 10, 4, 4, false},
+        {"coordenadas negativas", -10, -10, 12, 12, 0, 0, 4, 4, true},
+        // Caixas degeneradas só colidem estritamente dentro da outra
+        {"largura zero dentro", 2, 2, 0, 4, 0, 0, 8, 8, true},
+        {"largura zero na borda", 8, 2, 0, 4, 0, 0, 8, 8, false},
+        {"altura zero na borda", 2, 0, 4, 0, 0, 0, 8, 8, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := collision(tt.x1, tt.y1, tt.w1, tt.h1, tt.x2, tt.y2, tt.w2, tt.h2); got != tt.want {
+                t.Errorf("collision() = %v, want %v", got, tt.want)
+            }
+            // A ordem dos retângulos não importa
+            if got := collision(tt.x2, tt.y2, tt.w2, tt.h2, tt.x1, tt.y1, tt.w1, tt.h1); got != tt.want {
+                t.Errorf("collision() invertido = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestShoot(t *testing.T) {
+    tests := []struct {
+        name string
+        ammo int32
+        reloading bool
+        shots int
+        wantBullets int
+        wantAmmo int32
+    }{
+        {"um tiro", MAX_AMMO, false, 1, 1, MAX_AMMO - 1},
+        {"sem munição", 0, false, 1, 0, 0},
+        {"recarregando", MAX_AMMO, true, 1, 0, MAX_AMMO},
+        {"limite de tiros na tela", MAX_AMMO, false, MAX_BULLETS + 2, MAX_BULLETS, MAX_AMMO - MAX_BULLETS},
+        {"munição acaba antes do limite", 2, false, MAX_BULLETS, 2, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            players[0].ammo = tt.ammo
+            players[0].isReloading = tt.reloading
+            for i := 0; i < tt.shots; i++ {
+                shoot(0)
+            }
+            if got := countActiveBullets(); got != tt.wantBullets {
+                t.Errorf("balas ativas = %d, want %d", got, tt.wantBullets)
+            }
+            if players[0].ammo != tt.wantAmmo {
+                t.Errorf("ammo = %d, want %d", players[0].ammo, tt.wantAmmo)
+            }
+        })
+    }
+}
+
+func TestShootDirection(t *testing.T) {
+    tests := []struct {
+        name string
+        aim int8
+        velX, velY int16
+        width, height int8
+    }{
+        {"horizontal", AIM_RIGHT, BULLET_SPEED, 0, BULLET_WIDTH, BULLET_HEIGHT},
+        {"vertical", AIM_UP, 0, -BULLET_SPEED, BULLET_HEIGHT, BULLET_WIDTH},
+        {"diagonal para cima", AIM_UP_RIGHT, BULLET_DIAGONAL_SPEED, -BULLET_DIAGONAL_SPEED, BULLET_DIAGONAL, BULLET_DIAGONAL},
+        {"para trás", AIM_LEFT, -BULLET_SPEED, 0, BULLET_WIDTH, BULLET_HEIGHT},
+        {"para baixo", AIM_DOWN, 0, BULLET_SPEED, BULLET_HEIGHT, BULLET_WIDTH},
+        {"diagonal para baixo", AIM_DOWN_LEFT, -BULLET_DIAGONAL_SPEED, BULLET_DIAGONAL_SPEED, BULLET_DIAGONAL, BULLET_DIAGONAL},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            players[0].aimDirection = tt.aim
+            shoot(0)
+            b := &entities[activeOfKind(KIND_BULLET)[0]]
+            if b.velX != tt.velX || b.velY != tt.velY || b.owner != 0 {
+                t.Errorf("velocidade = (%d, %d) dono %d, want (%d, %d) dono 0", b.velX, b.velY, b.owner, tt.velX, tt.velY)
+            }
+            if b.width != tt.width || b.height != tt.height {
+                t.Errorf("caixa = %dx%d, want %dx%d", b.width, b.height, tt.width, tt.height)
+            }
+        })
+    }
+}
+
+func TestPadAim(t *testing.T) {
+    tests := []struct {
+        name string
+        gamepad uint8
+        onGround bool
+        want int8
+    }{
+        {"nada apertado", 0, true, -1},
+        {"direita", BUTTON_RIGHT, true, AIM_RIGHT},
+        {"cima e direita", BUTTON_UP | BUTTON_RIGHT, true, AIM_UP_RIGHT},
+        {"cima e esquerda", BUTTON_UP | BUTTON_LEFT, false, AIM_UP_LEFT},
+        {"baixo no chão", BUTTON_DOWN, true, -1},
+        {"baixo e direita no chão", BUTTON_DOWN | BUTTON_RIGHT, true, AIM_RIGHT},
+        {"baixo no ar", BUTTON_DOWN, false, AIM_DOWN},
+        {"baixo e esquerda no ar", BUTTON_DOWN | BUTTON_LEFT, false, AIM_DOWN_LEFT},
+        {"esquerda e direita", BUTTON_LEFT | BUTTON_RIGHT, true, -1},
+    }
+    for _, tt := range tests {
+        if got := padAim(tt.gamepad, tt.onGround); got != tt.want {
+            t.Errorf("%s: padAim = %d, want %d", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestUpdateAmmoReload(t *testing.T) {
+    setupGame(t)
+    pl := &players[0]
+    pl.ammo = 0
+
+    // O primeiro quadro sem munição só inicia a recarga
+    updateAmmo(0)
+    if !pl.isReloading || pl.reloadTimer != 0 {
+        t.Fatalf("recarga não iniciou: isReloading=%v reloadTimer=%d", pl.isReloading, pl.reloadTimer)
+    }
+
+    for i := 1; i < RELOAD_TIME; i++ {
+        updateAmmo(0)
+        if !pl.isReloading || pl.ammo != 0 {
+            t.Fatalf("recarga terminou cedo no quadro %d", i)
+        }
+    }
+
+    updateAmmo(0)
+    if pl.isReloading || pl.ammo != MAX_AMMO || pl.reloadTimer != 0 {
+        t.Errorf("após RELOAD_TIME: isReloading=%v ammo=%d reloadTimer=%d", pl.isReloading, pl.ammo, pl.reloadTimer)
+    }
+}
+
+func TestUpdateAmmoKeepsPartialMagazine(t *testing.T) {
+    setupGame(t)
+    players[0].ammo = 3
+    for i := 0; i < RELOAD_TIME*2; i++ {
+        updateAmmo(0)
+    }
+    if players[0].isReloading || players[0].ammo != 3 {
+        t.Errorf("recarregou com munição sobrando: isReloading=%v ammo=%d", players[0].isReloading, players[0].ammo)
+    }
+}
+
+func TestSpawnEnemyPoolExhaustion(t *testing.T) {
+    setupGame(t)
+    for i := 0; i < MAX_ENEMIES+2; i++ {
+        spawnEnemy(100+int32(i)*10, GROUND_Y-12, KIND_GROUND_ENEMY)
+    }
+
+    slots := activeOfKind(KIND_GROUND_ENEMY)
+    if len(slots) != MAX_ENEMIES {
+        t.Fatalf("inimigos ativos = %d, want %d", len(slots), MAX_ENEMIES)
+    }
+    // Os excedentes não sobrescrevem inimigos já em jogo
+    for i, slot := range slots {
+        if want := 100 + int32(i)*10; entities[slot].x != want {
+            t.Errorf("inimigo %d: x = %d, want %d", i, entities[slot].x, want)
+        }
+    }
+
+    // Um slot liberado volta a ser usado, por qualquer inimigo
+    entities[slots[1]].active = false
+    spawnEnemy(500, 80, KIND_FLYING_ENEMY)
+    if e := &entities[slots[1]]; !e.active || e.x != 500 || e.kind != KIND_FLYING_ENEMY {
+        t.Errorf("slot liberado não foi reutilizado: %+v", *e)
+    }
+}
+
+func TestSpawnObstaclePoolExhaustion(t *testing.T) {
+    setupGame(t)
+    for i := 0; i < MAX_OBSTACLES+2; i++ {
+        spawnObstacle(100+int32(i)*10, GROUND_Y-8, 8, 8, KIND_ROCK)
+    }
+
+    slots := activeOfKind(KIND_ROCK)
+    if len(slots) != MAX_OBSTACLES {
+        t.Fatalf("obstáculos ativos = %d, want %d", len(slots), MAX_OBSTACLES)
+    }
+    for i, slot := range slots {
+        if want := 100 + int32(i)*10; entities[slot].x != want {
+            t.Errorf("obstáculo %d: x = %d, want %d", i, entities[slot].x, want)
+        }
+    }
+
+    // O limite é por time: os tiros continuam tendo espaço
+    shoot(0)
+    if countActiveBullets() != 1 {
+        t.Errorf("tiro não coube no pool com os obstáculos no limite")
+    }
+}
+
+func TestEntityCollisions(t *testing.T) {
+    tests := []struct {
+        name string
+        kind int8
+        x, y int32
+        wantTarget bool // O alvo continua ativo?
+        wantBullet bool
+        wantScore int32
+    }{
+        {"inimigo abatido", KIND_FLYING_ENEMY, 120, 80, false, false, 10},
+        {"tiro inimigo interceptado", KIND_ENEMY_BULLET, 120, 80, false, false, 5},
+        {"interceptação com folga", KIND_ENEMY_BULLET, 125, 80, false, false, 5},
+        {"obstáculo não é alvo", KIND_ROCK, 120, 80, true, true, 0},
+        {"longe demais", KIND_FLYING_ENEMY, 130, 80, true, true, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            target := spawnEntity(tt.kind, tt.x, tt.y, 0, 0)
+            bullet := spawnEntity(KIND_BULLET, 120, 80, 0, 0)
+            entities[bullet].owner = 0
+            checkCollisions()
+            // A explosão pode ocupar o slot liberado, então o tipo também conta
+            targetAlive := entities[target].active && entities[target].kind == tt.kind
+            bulletAlive := entities[bullet].active && entities[bullet].kind == KIND_BULLET
+            if targetAlive != tt.wantTarget || bulletAlive != tt.wantBullet {
+                t.Errorf("alvo ativo=%v tiro ativo=%v, want %v %v", targetAlive, bulletAlive, tt.wantTarget, tt.wantBullet)
+            }
+            if score != tt.wantScore || players[0].score != tt.wantScore {
+                t.Errorf("score = %d (jogador %d), want %d", score, players[0].score, tt.wantScore)
+            }
+        })
+    }
+}
+
+func TestUpdateSpeeds(t *testing.T) {
+    tests := []struct {
+        score int32
+        tier int8
+        playerSpeed, enemySpeed, bulletSpeed, jumpPower int16
+    }{
+        {0, 0, 256, 256, 768, -2304},
+        {99, 0, 357, 306, 869, -2228},
+        {100, 1, 358, 307, 870, -2228},
+        {250, 1, 512, 384, 1024, -2112},
+        {300, 2, 563, 409, 1075, -2074},
+        {500, 3, 768, 512, 1280, -1920},
+        {900, 3, 768, 512, 1280, -1920}, // A rampa para no máximo
+    }
+    for _, tt := range tests {
+        score = tt.score
+        updateSpeeds()
+        if difficultyTier != tt.tier || currentPlayerSpeed != tt.playerSpeed || currentEnemySpeed != tt.enemySpeed ||
+           currentBulletSpeed != tt.bulletSpeed || currentJumpPower != tt.jumpPower {
+            t.Errorf("score %d: tier=%d player=%d enemy=%d bullet=%d jump=%d", tt.score,
+                difficultyTier, currentPlayerSpeed, currentEnemySpeed, currentBulletSpeed, currentJumpPower)
+        }
+    }
+
+    // Sem degraus: cada ponto muda a velocidade em no máximo 2/256 px
+    prev := int16(PLAYER_SPEED_START)
+    for score = 0; score <= DIFFICULTY_RAMP_SCORE; score++ {
+        updateSpeeds()
+        if step := currentPlayerSpeed - prev; step < 0 || step > 2 {
+            t.Fatalf("score %d: velocidade pulou de %d para %d", score, prev, currentPlayerSpeed)
+        }
+        prev = currentPlayerSpeed
+    }
+
+    // Uma partida nova volta ao nível inicial
+    score = 500
+    updateSpeeds()
+    setupGame(t)
+    if difficultyTier != 0 || currentPlayerSpeed != PLAYER_SPEED_START {
+        t.Errorf("nova partida manteve o nível anterior: tier=%d speed=%d", difficultyTier, currentPlayerSpeed)
+    }
+}
+
+func TestPauseFreezesSimulation(t *testing.T) {
+    setupGame(t)
+    host.step()
+    x := players[0].x
+
+    host.gamepads[0] = BUTTON_DOWN | BUTTON_2
+    host.step()
+    if gameState != STATE_PAUSED {
+        t.Fatalf("gameState = %d, want STATE_PAUSED", gameState)
+    }
+    if countActiveBullets() != 0 {
+        t.Errorf("a combinação de pausa também atirou")
+    }
+
+    host.gamepads[0] = 0
+    for i := 0; i < 30; i++ {
+        host.step()
+    }
+    if players[0].x != x {
+        t.Errorf("jogador andou durante a pausa: x=%d, want %d", players[0].x, x)
+    }
+
+    // RESUME só volta depois que o botão é solto
+    host.gamepads[0] = BUTTON_1
+    host.step()
+    host.step()
+    if gameState != STATE_PAUSED {
+        t.Fatalf("voltou ao jogo com o botão ainda apertado")
+    }
+    host.gamepads[0] = 0
+    host.step()
+    if gameState != STATE_PLAYING {
+        t.Fatalf("gameState = %d, want STATE_PLAYING", gameState)
+    }
+    if (players[0].flags & 0x01) == 0 {
+        t.Errorf("o botão de confirmar fez o jogador pular")
+    }
+}
+
+// Uma partida pausada no meio ainda se reproduz igual: os quadros pausados
+// não contam no quadro da morte gravado
+func TestPausedReplay(t *testing.T) {
+    setupGame(t)
+    startRecording(12345)
+    beginRun(12345)
+    defer abortReplay()
+    for i := 0; i < 5; i++ {
+        host.step()
+    }
+    host.gamepads[0] = BUTTON_DOWN | BUTTON_2
+    host.step()
+    host.gamepads[0] = 0
+    for i := 0; i < 100; i++ {
+        host.step()
+    }
+    host.gamepads[0] = BUTTON_1
+    host.step()
+    host.gamepads[0] = 0
+    host.step()
+    if gameState != STATE_PLAYING {
+        t.Fatalf("gameState = %d depois da pausa, want STATE_PLAYING", gameState)
+    }
+    for i := 0; i < 20000 && gameState == STATE_PLAYING; i++ {
+        host.step()
+    }
+    if gameState != STATE_GAME_OVER {
+        t.Fatalf("a partida não terminou")
+    }
+    wantFrame, wantScore := replayResult()
+
+    gameState = STATE_PLAYING
+    startPlayback()
+    for gameState == STATE_PLAYING {
+        host.step()
+    }
+    if !playbackMatched || gameFrame != wantFrame || score != wantScore {
+        t.Errorf("reprodução terminou no quadro %d com %d pontos, want quadro %d com %d",
+            gameFrame, score, wantFrame, wantScore)
+    }
+}
+
+func TestLoadReplay(t *testing.T) {
+    // Cabeçalho só com o jogador 1 e runs de 5 bytes
+    header := make([]uint8, REPLAY_HEADER_SIZE)
+    header[4] = 1
+    tests := []struct {
+        name string
+        runs []uint8
+        ok bool
+    }{
+        {"sem runs", nil, true},
+        {"dois runs", []uint8{3, 0, 0, 0, BUTTON_1, 255, 0, 0, 0, 0}, true},
+        {"run pela metade", []uint8{3, 0, 0, 0}, false},
+        {"zero repetições", []uint8{3, 0, 0, 0, BUTTON_1, 0, 0, 0, 0, 0}, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            data := append(append([]uint8{}, header...), tt.runs...)
+            if ok := loadReplay(data); ok != tt.ok {
+                t.Errorf("loadReplay = %v, want %v", ok, tt.ok)
+            }
+        })
+    }
+}
+
+// Buffer cheio: a gravação para, o cabeçalho guarda até onde foi e a
+// reprodução termina ali conferindo aquele ponto
+func TestPartialReplay(t *testing.T) {
+    setupGame(t)
+    startRecording(12345)
+    beginRun(12345)
+    defer abortReplay()
+    // Um run novo a cada quadro
+    var scores []int32
+    for i := 0; i < 200 && gameState == STATE_PLAYING; i++ {
+        host.gamepads[0] = uint8(i%2) * BUTTON_2
+        host.step()
+        scores = append(scores, score)
+    }
+    const cut = 150
+    if len(scores) < cut {
+        t.Fatalf("a partida acabou no quadro %d", len(scores))
+    }
+    full := append([]uint8{}, replayBytes()...)
+
+    // Estouro: o que já foi gravado fica e vira um replay parcial
+    replayLen = REPLAY_MAX_SIZE - replayRunSize + 1
+    replayMode = REPLAY_RECORDING
+    frameGamepads[0] ^= BUTTON_1
+    recordInput()
+    if !replayTruncated || !replayPartial() || replayLen != REPLAY_MAX_SIZE-replayRunSize+1 {
+        t.Fatalf("estouro: truncado %v, parcial %v, %d bytes", replayTruncated, replayPartial(), replayLen)
+    }
+    if frame, got := replayResult(); frame != gameFrame-1 || got != score {
+        t.Errorf("cabeçalho = quadro %d com %d, want %d com %d", frame, got, gameFrame-1, score)
+    }
+    frameGamepads[0] ^= BUTTON_1
+    recordInput()
+    finishRecording()
+    if replayLen != REPLAY_MAX_SIZE-replayRunSize+1 || !replayAvailable() {
+        t.Errorf("depois do estouro: %d bytes, disponível %v", replayLen, replayAvailable())
+    }
+
+    // Reprodução de um replay parcial dos primeiros quadros da partida
+    data := full[:REPLAY_HEADER_SIZE+cut*int(replayRunSize)]
+    data[4] |= REPLAY_PARTIAL
+    putU32(data, 10, cut)
+    putU32(data, 14, uint32(scores[cut-1]))
+    if !loadReplay(data) {
+        t.Fatalf("replay parcial recusado")
+    }
+    gameState = STATE_PLAYING
+    startPlayback()
+    for i := 0; i < 1000 && gameState == STATE_PLAYING; i++ {
+        host.step()
+    }
+    if !playbackDone || !playbackMatched || gameFrame != cut {
+        t.Errorf("reprodução parou no quadro %d (conferiu %v), want %d", gameFrame, playbackMatched, cut)
+    }
+}
+
+// O som se liga e desliga no menu de pausa, e a escolha fica no perfil
+func TestPauseMute(t *testing.T) {
+    setupGame(t)
+    tapButtons(BUTTON_DOWN | BUTTON_2)
+    if gameState != STATE_PAUSED {
+        t.Fatalf("gameState = %d, want STATE_PAUSED", gameState)
+    }
+    tests := []struct {
+        name string
+        down int // Quantas opções descer a partir da atual
+        want uint8
+    }{
+        {"desliga os efeitos", PAUSE_SFX - PAUSE_RESUME, SETTING_MUTE_SFX},
+        {"desliga a música", PAUSE_MUSIC - PAUSE_SFX, SETTING_MUTE_SFX | SETTING_MUTE_MUSIC},
+        {"religa a música", 0, SETTING_MUTE_SFX},
+    }
+    for _, tt := range tests {
+        for i := 0; i < tt.down; i++ {
+            tapButtons(BUTTON_DOWN)
+        }
+        tapButtons(BUTTON_1)
+        if gameState != STATE_PAUSED || settings != tt.want {
+            t.Fatalf("%s: gameState %d, settings %b; want pausado com %b", tt.name, gameState, settings, tt.want)
+        }
+        loadSave()
+        if settings != tt.want {
+            t.Errorf("%s: settings salvo = %b, want %b", tt.name, settings, tt.want)
+        }
+    }
+}
+
+// Aperta e solta os botões do jogador 1, um quadro cada
+func tapButtons(buttons uint8) {
+    host.gamepads[0] = buttons
+    host.step()
+    host.gamepads[0] = 0
+    host.step()
+}
+
+// Troca uma opção de som no menu de pausa e volta ao jogo
+func togglePauseSetting(t *testing.T, option int) {
+    t.Helper()
+    tapButtons(BUTTON_DOWN | BUTTON_2)
+    for i := 0; i < option; i++ {
+        tapButtons(BUTTON_DOWN)
+    }
+    tapButtons(BUTTON_1)
+    for i := 0; i < option; i++ {
+        tapButtons(BUTTON_UP)
+    }
+    tapButtons(BUTTON_1)
+    if gameState != STATE_PLAYING {
+        t.Fatalf("gameState = %d depois da pausa, want STATE_PLAYING", gameState)
+    }
+}
+
+// Tons tocados num quadro com os botões dados; notes separa os da música
+func stepTones(buttons uint8) (effects, notes int) {
+    host.gamepads[0] = buttons
+    host.step()
+    host.gamepads[0] = 0
+    for _, tone := range host.tones {
+        if tone.flags&TONE_NOTE_MODE != 0 {
+            notes++
+        } else {
+            effects++
+        }
+    }
+    return effects, notes
+}
+
+func TestMuteSfx(t *testing.T) {
+    tests := []struct {
+        name string
+        mute bool
+    }{
+        {"com efeitos", false},
+        {"efeitos desligados na pausa", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            if tt.mute {
+                togglePauseSetting(t, PAUSE_SFX)
+            }
+            effects, _ := stepTones(BUTTON_1) // Pulo
+            if (effects == 0) != tt.mute {
+                t.Errorf("%d tons de efeito no pulo, desligado = %v", effects, tt.mute)
+            }
+        })
+    }
+}
+
+func TestMuteMusic(t *testing.T) {
+    tests := []struct {
+        name string
+        mute bool
+    }{
+        {"com música", false},
+        {"música desligada na pausa", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            if tt.mute {
+                togglePauseSetting(t, PAUSE_MUSIC)
+            }
+            notes := 0
+            for i := 0; i < 120; i++ {
+                _, n := stepTones(0)
+                notes += n
+            }
+            if (notes == 0) != tt.mute {
+                t.Errorf("%d notas em dois segundos, desligada = %v", notes, tt.mute)
+            }
+        })
+    }
+}
+
+func TestHostTonesPerFrame(t *testing.T) {
+    setupGame(t)
+    for i := 0; i < 600; i++ {
+        host.step()
+        for _, tone := range host.tones {
+            if tone.frame != gameFrame {
+                t.Fatalf("tom do quadro %d ainda guardado no quadro %d", tone.frame, gameFrame)
+            }
+        }
+    }
+}
+
+func TestTextMetrics(t *testing.T) {
+    tests := []struct {
+        text string
+        want int32
+    }{
+        {"", 0},
+        {"I", 3},
+        {"AMMO:", 5*4 + 1 + 4*FONT_SPACING},
+        {"ammo:", 5*4 + 1 + 4*FONT_SPACING}, // Minúsculas usam as maiúsculas
+        {"\x7f", 4}, // Byte sem glifo usa o reserva
+    }
+    for _, tt := range tests {
+        if got := textWidth(tt.text); got != tt.want {
+            t.Errorf("textWidth(%q) = %d, want %d", tt.text, got, tt.want)
+        }
+    }
+
+    // Números usam os mesmos glifos do texto
+    for _, num := range []int32{0, 7, 1234, 900000} {
+        var digits [10]byte
+        n := numberDigits(num, &digits)
+        text := make([]byte, n)
+        for i := 0; i < n; i++ {
+            text[n-1-i] = digits[i]
+        }
+        if got, want := numberWidth(num), textWidth(string(text)); got != want {
+            t.Errorf("numberWidth(%d) = %d, want %d", num, got, want)
+        }
+    }
+}
+
+func TestBlitFlags(t *testing.T) {
+    // Pixels acesos numa área pequena a partir da origem, linha por linha
+    lit := func(w, h int32) []string {
+        rows := make([]string, h)
+        for y := int32(0); y < h; y++ {
+            row := make([]byte, w)
+            for x := int32(0); x < w; x++ {
+                row[x] = '.'
+                if host.framebuffer[y*SCREEN_WIDTH+x] != 0 {
+                    row[x] = '#'
+                }
+            }
+            rows[y] = string(row)
+        }
+        return rows
+    }
+    tests := []struct {
+        name string
+        flags uint32
+        w, h int32
+        want []string
+    }{
+        {"normal", 0, 6, 3, []string{"######", "##....", "##...."}},
+        {"espelhada", BLIT_FLIP_X, 6, 3, []string{"######", "....##", "....##"}},
+        {"girada", BLIT_ROTATE, 3, 6, []string{"#..", "#..", "#..", "#..", "###", "###"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            *host = memoryPlatform{}
+            drawSprite(SPRITE_WEAPON, 0, 0, 0, 0x04, tt.flags)
+            // Uma coluna e uma linha a mais confirmam que nada vazou
+            want := append([]string{}, tt.want...)
+            want = append(want, strings.Repeat(".", int(tt.w)))
+            got := lit(tt.w+1, tt.h+1)
+            for y := range want {
+                if got[y] != want[y]+"." {
+                    t.Errorf("linha %d = %q, want %q", y, got[y], want[y]+".")
+                }
+            }
+        })
+    }
+}
+
+func TestCoopAmmoFits(t *testing.T) {
+    tests := []struct {
+        name string
+        ammo int32
+        magazine bool
+        want int // Balas desenhadas
+    }{
+        {"pente normal", MAX_AMMO, false, MAX_AMMO},
+        {"pente extra", MAX_AMMO + MAGAZINE_BONUS, true, MAX_AMMO + MAGAZINE_BONUS},
+        {"sobra acima do pente", MAX_AMMO + MAGAZINE_BONUS + 6, true, MAX_AMMO + MAGAZINE_BONUS},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            players[1].joined = true
+            initPlayers()
+            defer func() { players[1].joined = false }()
+            players[0].ammo = tt.ammo
+            if tt.magazine {
+                players[0].powerups[POWERUP_MAGAZINE] = POWERUP_FRAMES
+            }
+            host.framebuffer = [SCREEN_WIDTH * SCREEN_HEIGHT]uint8{}
+            drawCoopUI()
+
+            // Balas são os pixels da cor 4 na linha do meio do ícone
+            y := int32(15 + 1)
+            bullets := 0
+            for x := int32(COOP_AMMO_X); x < SCREEN_WIDTH; x++ {
+                lit := host.framebuffer[y*SCREEN_WIDTH+x] == 3
+                if lit && x >= COOP_HEARTS_X-COOP_AMMO_GAP && x < COOP_HEARTS_X+PLAYER_MAX_HP*(SPRITE_HEART_WIDTH+1) {
+                    t.Fatalf("bala em x=%d, em cima dos corações", x)
+                }
+                if lit && x < COOP_HEARTS_X && host.framebuffer[y*SCREEN_WIDTH+x-1] != 3 {
+                    bullets++
+                }
+            }
+            if bullets != tt.want {
+                t.Errorf("%d balas desenhadas, want %d", bullets, tt.want)
+            }
+        })
+    }
+}
+
+func TestEnemyDamage(t *testing.T) {
+    setupGame(t)
+    heavy := spawnEntity(KIND_HEAVY_ENEMY, 120, 80, 0, 0)
+    fire := func(velX, velY int16) int {
+        b := spawnEntity(KIND_BULLET, 122, 84, velX, velY)
+        entities[b].owner = 0
+        checkCollisions()
+        return b
+    }
+
+    for hit := 1; hit < 3; hit++ {
+        fire(BULLET_SPEED, 0)
+        e := &entities[heavy]
+        if !e.active || e.hp != 3-int8(hit) || e.flash != HIT_FLASH_FRAMES {
+            t.Fatalf("acerto %d: ativo=%v hp=%d flash=%d", hit, e.active, e.hp, e.flash)
+        }
+    }
+    fire(BULLET_SPEED, 0)
+    if entities[heavy].active && entities[heavy].kind == KIND_HEAVY_ENEMY {
+        t.Fatalf("inimigo blindado sobreviveu a três tiros")
+    }
+    if score != entityKinds[KIND_HEAVY_ENEMY].points {
+        t.Errorf("score = %d, want %d", score, entityKinds[KIND_HEAVY_ENEMY].points)
+    }
+
+    // O escudo segura tiros de frente, mas não os de baixo
+    clearEntities()
+    shielded := spawnEntity(KIND_SHIELDED_ENEMY, 120, 80, 0, 0)
+    if b := fire(BULLET_SPEED, 0); entities[b].active || entities[shielded].hp != 2 {
+        t.Errorf("tiro de frente: tiro ativo=%v hp=%d, want consumido e hp 2", entities[b].active, entities[shielded].hp)
+    }
+    if fire(0, -BULLET_SPEED); entities[shielded].hp != 1 {
+        t.Errorf("tiro de baixo: hp=%d, want 1", entities[shielded].hp)
+    }
+}
+
+func TestPlayerDamage(t *testing.T) {
+    setupGame(t)
+    pl := &players[0]
+    shot := func() int {
+        return spawnEntity(KIND_ENEMY_BULLET, pl.x+2, pl.y+2, 0, 0)
+    }
+
+    first := shot()
+    checkCollisions()
+    if pl.hp != PLAYER_MAX_HP-1 || pl.invuln != PLAYER_INVULN_FRAMES || entities[first].active {
+        t.Fatalf("primeiro tiro: hp=%d invuln=%d tiro ativo=%v", pl.hp, pl.invuln, entities[first].active)
+    }
+
+    // Invencível: o tiro atravessa sem tirar vida
+    second := shot()
+    checkCollisions()
+    if pl.hp != PLAYER_MAX_HP-1 || !entities[second].active {
+        t.Fatalf("tiro durante a invencibilidade: hp=%d tiro ativo=%v", pl.hp, entities[second].active)
+    }
+
+    // O espeto tira a vida toda de uma vez
+    clearEntities()
+    pl.invuln = 0
+    spawnObstacle(pl.x, pl.y+4, 6, 8, KIND_SPIKE)
+    checkCollisions()
+    if pl.hp != 0 || (pl.flags&0x02) != 0 {
+        t.Errorf("espeto: hp=%d vivo=%v", pl.hp, (pl.flags&0x02) != 0)
+    }
+}
+
+func TestBossFight(t *testing.T) {
+    setupGame(t)
+    pl := &players[0]
+    hit := func(slot int) {
+        e := &entities[slot]
+        b := spawnEntity(KIND_BULLET, e.x+int32(e.width)/2, e.y+int32(e.height)/2, 0, 0)
+        entities[b].owner = 0
+        checkCollisions()
+    }
+    run := func(frames int) {
+        for i := 0; i < frames; i++ {
+            pl.invuln = PLAYER_INVULN_FRAMES // Os tiros do chefe não interessam aqui
+            updateGame()
+        }
+    }
+
+    score = BOSS_FIRST_SCORE
+    run(1)
+    if bossState != BOSS_ENTERING {
+        t.Fatalf("bossState = %d ao chegar em %d pontos, want BOSS_ENTERING", bossState, BOSS_FIRST_SCORE)
+    }
+    lockedX := cameraX
+    run(120)
+    if bossState != BOSS_FIGHTING {
+        t.Fatalf("bossState = %d depois da entrada, want BOSS_FIGHTING", bossState)
+    }
+    run(300)
+    if cameraX != lockedX || pl.x > cameraX+BOSS_PLAYER_MAX_X {
+        t.Errorf("câmera %d (travada em %d), jogador em %d", cameraX, lockedX, pl.x)
+    }
+    if n := countTeam(TEAM_ENEMY) + countTeam(TEAM_HAZARD); n != 0 {
+        t.Errorf("%d inimigos ou obstáculos surgiram durante a luta", n)
+    }
+
+    // Com as torretas vivas o núcleo não perde vida
+    core := bossParts[BOSS_CORE]
+    coreHP := entities[core].hp
+    hit(core)
+    if entities[core].hp != coreHP {
+        t.Fatalf("núcleo perdeu vida com as torretas vivas: hp=%d, want %d", entities[core].hp, coreHP)
+    }
+    for part := BOSS_TOP_TURRET; part < BOSS_PARTS; part++ {
+        for bossPartAlive(part) {
+            hit(bossParts[part])
+        }
+    }
+    run(1)
+
+    before := score
+    for i := 0; bossPartAlive(BOSS_CORE); i++ {
+        if i > 100 {
+            t.Fatalf("núcleo não caiu: hp=%d", entities[core].hp)
+        }
+        hit(core)
+    }
+    if score-before != BOSS_BONUS {
+        t.Errorf("bônus = %d, want %d", score-before, BOSS_BONUS)
+    }
+    run(60)
+    if bossActive() || nextBossScore != before+BOSS_BONUS+BOSS_INTERVAL {
+        t.Errorf("depois do chefe: ativo=%v próximo em %d, want %d", bossActive(), nextBossScore, before+BOSS_BONUS+BOSS_INTERVAL)
+    }
+    if cameraX == lockedX {
+        t.Errorf("a câmera continuou travada depois do chefe")
+    }
+}
+
+func TestPowerups(t *testing.T) {
+    setupGame(t)
+    pl := &players[0]
+    collect := func(powerup int) {
+        slot := spawnEntity(KIND_PICKUP, pl.x, pl.y, 0, 0)
+        entities[slot].variant = int8(powerup)
+        checkCollisions()
+        if entities[slot].active || pl.powerups[powerup] != powerupDurations[powerup] {
+            t.Fatalf("power-up %d: cápsula ativa=%v tempo=%d", powerup, entities[slot].active, pl.powerups[powerup])
+        }
+    }
+
+    // Invencível ainda recolhe
+    pl.invuln = PLAYER_INVULN_FRAMES
+    collect(POWERUP_SPREAD)
+    shoot(0)
+    if n := countActiveBullets(); n != 3 || pl.ammo != MAX_AMMO-1 {
+        t.Errorf("leque: %d tiros e ammo=%d, want 3 e %d", n, pl.ammo, MAX_AMMO-1)
+    }
+
+    collect(POWERUP_RAPID)
+    pl.ammo = 0
+    for i := 0; i <= RELOAD_TIME/2; i++ {
+        updateAmmo(0)
+    }
+    if pl.isReloading || pl.ammo != MAX_AMMO {
+        t.Errorf("recarga rápida: isReloading=%v ammo=%d", pl.isReloading, pl.ammo)
+    }
+
+    collect(POWERUP_MAGAZINE)
+    if pl.ammo != MAX_AMMO+MAGAZINE_BONUS {
+        t.Errorf("pente extra: ammo=%d, want %d", pl.ammo, MAX_AMMO+MAGAZINE_BONUS)
+    }
+    for i := 0; i < POWERUP_FRAMES; i++ {
+        updatePowerups(0)
+    }
+    if pl.ammo != MAX_AMMO || hasPowerup(0, POWERUP_MAGAZINE) {
+        t.Errorf("fim do pente extra: ammo=%d ativo=%v", pl.ammo, hasPowerup(0, POWERUP_MAGAZINE))
+    }
+
+    collect(POWERUP_MULTIPLIER)
+    before := score
+    addScore(0, 10)
+    if score-before != 20 {
+        t.Errorf("multiplicador: +%d pontos, want 20", score-before)
+    }
+
+    // O escudo absorve um acerto, mesmo o do espeto
+    collect(POWERUP_SHIELD)
+    pl.invuln = 0
+    hurtPlayer(0, PLAYER_MAX_HP)
+    if pl.hp != PLAYER_MAX_HP || hasPowerup(0, POWERUP_SHIELD) {
+        t.Fatalf("escudo: hp=%d escudo=%v", pl.hp, hasPowerup(0, POWERUP_SHIELD))
+    }
+    pl.invuln = 0
+    hurtPlayer(0, 1)
+    if pl.hp != PLAYER_MAX_HP-1 {
+        t.Errorf("sem escudo: hp=%d, want %d", pl.hp, PLAYER_MAX_HP-1)
+    }
+}
+
+func TestAirborneDownShot(t *testing.T) {
+    setupGame(t)
+    host.gamepads[0] = BUTTON_1
+    host.step()
+    host.gamepads[0] = 0
+    host.step()
+    if (players[0].flags & 0x01) != 0 {
+        t.Fatalf("o jogador não pulou")
+    }
+
+    // No ar a combinação de pausa vira tiro para baixo
+    host.gamepads[0] = BUTTON_DOWN | BUTTON_2
+    host.step()
+    if gameState != STATE_PLAYING {
+        t.Fatalf("gameState = %d, want STATE_PLAYING", gameState)
+    }
+    bullets := activeOfKind(KIND_BULLET)
+    if len(bullets) != 1 || entities[bullets[0]].velY <= 0 || players[0].aimDirection != AIM_DOWN {
+        t.Fatalf("tiros=%d mira=%d, want um tiro para baixo", len(bullets), players[0].aimDirection)
+    }
+
+    // Ao pousar a mira volta para a frente
+    host.gamepads[0] = 0
+    for i := 0; i < 60 && (players[0].flags&0x01) == 0; i++ {
+        host.step()
+    }
+    if players[0].aimDirection != AIM_RIGHT {
+        t.Errorf("mira depois de pousar = %d, want AIM_RIGHT", players[0].aimDirection)
+    }
+}
+
+func TestAimVector(t *testing.T) {
+    tests := []struct {
+        dx, dy int32
+        velX, velY int16
+    }{
+        {5, 0, BULLET_SPEED, 0},
+        {3, 4, 3 * FIXED_ONE, 4 * FIXED_ONE},
+        {-30, -40, -3 * FIXED_ONE, -4 * FIXED_ONE},
+        {100, 8, 1276, 102},
+        {1, -1, 905, -905},          // Diagonal exata
+        {0, 0, BULLET_SPEED, 0},     // Vetor nulo: para a frente
+    }
+    for _, tt := range tests {
+        velX, velY := aimVector(tt.dx, tt.dy, MOUSE_SHOT_SPEED)
+        if velX != tt.velX || velY != tt.velY {
+            t.Errorf("aimVector(%d, %d) = (%d, %d), want (%d, %d)", tt.dx, tt.dy, velX, velY, tt.velX, tt.velY)
+        }
+    }
+}
+
+func TestMouseShot(t *testing.T) {
+    setupGame(t)
+    startRecording(12345)
+    defer abortReplay()
+    host.mouseX, host.mouseY = 100, 20
+    host.mouseButtons = MOUSE_RIGHT
+    host.step()
+
+    bullets := activeOfKind(KIND_BULLET)
+    if len(bullets) != 1 {
+        t.Fatalf("%d tiros, want 1", len(bullets))
+    }
+    b := &entities[bullets[0]]
+    if b.velX <= 0 || b.velY >= 0 || players[0].aimDirection != AIM_UP_RIGHT {
+        t.Errorf("tiro (%d, %d) mira %d, want para cima e para a frente", b.velX, b.velY, players[0].aimDirection)
+    }
+
+    // No chão, com o cursor abaixo da arma, o tiro sai reto
+    host.mouseButtons = 0
+    host.step()
+    host.mouseX, host.mouseY = 150, 150
+    host.mouseButtons = MOUSE_RIGHT
+    host.step()
+    b = &entities[activeOfKind(KIND_BULLET)[1]]
+    if b.velX != MOUSE_SHOT_SPEED || b.velY != 0 {
+        t.Errorf("tiro no chão = (%d, %d), want (%d, 0)", b.velX, b.velY, MOUSE_SHOT_SPEED)
+    }
+
+    // O replay guarda a posição só com o botão direito apertado
+    runs := replayBytes()[REPLAY_HEADER_SIZE:]
+    if len(runs) != 3*int(replayRunSize) {
+        t.Fatalf("%d bytes de runs, want %d", len(runs), 3*replayRunSize)
+    }
+    for i, want := range [][2]uint8{{100, 20}, {0, 0}, {150, 150}} {
+        run := runs[i*int(replayRunSize):]
+        if run[2] != want[0] || run[3] != want[1] {
+            t.Errorf("run %d: mouse (%d, %d), want (%d, %d)", i, run[2], run[3], want[0], want[1])
+        }
+    }
+}
+
+func TestIntegrate(t *testing.T) {
+    tests := []struct {
+        name string
+        vel int16
+        want []int32 // Posição depois de cada quadro
+    }{
+        {"1.5 px", 3 * FIXED_ONE / 2, []int32{1, 3, 4, 6}},
+        {"-0.5 px", -FIXED_ONE / 2, []int32{-1, -1, -2, -2}},
+        {"0.25 px", FIXED_ONE / 4, []int32{0, 0, 0, 1}},
+    }
+    for _, tt := range tests {
+        pos, frac := int32(0), uint8(0)
+        for i, want := range tt.want {
+            integrate(&pos, &frac, tt.vel)
+            if pos != want {
+                t.Errorf("%s: quadro %d em %d, want %d", tt.name, i+1, pos, want)
+                break
+            }
+        }
+    }
+}
+
+func TestJumpHeight(t *testing.T) {
+    // Altura máxima do pulo segurando o botão por hold quadros
+    peak := func(hold int) int32 {
+        setupGame(t)
+        pl := &players[0]
+        top := pl.y
+        for i := 0; i < 60; i++ {
+            host.gamepads[0] = 0
+            if i < hold {
+                host.gamepads[0] = BUTTON_1
+            }
+            pl.invuln = PLAYER_INVULN_FRAMES
+            host.step()
+            if pl.y < top {
+                top = pl.y
+            }
+        }
+        return GROUND_Y - PLAYER_HEIGHT - top
+    }
+
+    tap, half, full := peak(1), peak(PLAYER_JUMP_HOLD_FRAMES/2), peak(30)
+    if !(tap < half && half < full) {
+        t.Errorf("alturas tap=%d meio=%d cheio=%d, want crescentes", tap, half, full)
+    }
+    if full < 60 || full > 68 {
+        t.Errorf("pulo cheio = %d px, want perto dos 66 do pulo antigo", full)
+    }
+    if peak(PLAYER_JUMP_HOLD_FRAMES+10) != full {
+        t.Errorf("segurar além de PLAYER_JUMP_HOLD_FRAMES mudou a altura")
+    }
+}
+
+func TestJumpFeel(t *testing.T) {
+    // Solta o jogador no ar a height pixels do chão
+    drop := func(height int32, coyote uint8) {
+        pl := &players[0]
+        pl.y = GROUND_Y - PLAYER_HEIGHT - height
+        pl.velY = 0
+        pl.flags &^= 0x01
+        pl.coyote = coyote
+        pl.invuln = PLAYER_INVULN_FRAMES
+    }
+    // O jogador pula em algum dos próximos quadros sem apertar nada?
+    jumpsLater := func(frames int) bool {
+        for i := 0; i < frames; i++ {
+            host.gamepads[0] = 0
+            host.step()
+            if players[0].velY < 0 {
+                return true
+            }
+        }
+        return false
+    }
+    press := func() {
+        players[0].prevGamepad = 0
+        host.gamepads[0] = BUTTON_1
+        host.step()
+        host.gamepads[0] = 0
+    }
+
+    // Coyote time: saiu do chão sem pular e aperta depois de late quadros
+    for late := 0; late <= PLAYER_COYOTE_FRAMES; late++ {
+        setupGame(t)
+        host.step() // No chão: o coyote enche
+        drop(30, players[0].coyote)
+        for i := 0; i < late; i++ {
+            host.step()
+        }
+        press()
+        want := late < PLAYER_COYOTE_FRAMES
+        if got := players[0].velY < 0; got != want {
+            t.Errorf("coyote: apertou %d quadros depois de sair do chão: pulou=%v, want %v", late, got, want)
+        }
+    }
+
+    // Buffer: apertou no ar, pouco antes de pousar; pula assim que pousa
+    tests := []struct {
+        name string
+        height int32
+        want bool
+    }{
+        {"perto do chão", 10, true},
+        {"longe do chão", 60, false},
+    }
+    for _, tt := range tests {
+        setupGame(t)
+        drop(tt.height, 0)
+        press()
+        if got := jumpsLater(30); got != tt.want {
+            t.Errorf("buffer %s: pulou=%v, want %v", tt.name, got, tt.want)
+        }
+    }
+
+    // Pulo cortado: soltando o botão, a subida cai para o limite
+    setupGame(t)
+    press()
+    host.step()
+    if players[0].velY < -PLAYER_JUMP_CUT_VELOCITY {
+        t.Errorf("velY depois de soltar = %d, want >= %d", players[0].velY, -PLAYER_JUMP_CUT_VELOCITY)
+    }
+}
+
+// Colunas do terreno a partir de col, por cima do que foi gerado
+func setColumns(col int32, columns []terrainColumn) {
+    for i, c := range columns {
+        terrain[(col+int32(i))%TERRAIN_COLUMNS] = c
+    }
+}
+
+// count colunas iguais
+func columns(count int, c terrainColumn) []terrainColumn {
+    out := make([]terrainColumn, count)
+    for i := range out {
+        out[i] = c
+    }
+    return out
+}
+
+func TestTerrainCollision(t *testing.T) {
+    slope := append(columns(4, terrainColumn{slope: -TERRAIN_STEP}),
+        columns(20, terrainColumn{ground: GROUND_Y - 16})...)
+    for i := range slope[:4] {
+        slope[i].ground = int16(GROUND_Y - TERRAIN_STEP*i)
+    }
+
+    tests := []struct {
+        name string
+        from int32
+        columns []terrainColumn
+        hold int // Quadros segurando o pulo
+        frames int
+        alive bool
+        y int32 // y esperado no fim
+        maxX int32 // 0 = não importa
+    }{
+        {"degrau alto é parede", 4, columns(20, terrainColumn{ground: GROUND_Y - 16}), 0, 60,
+            true, GROUND_Y - PLAYER_HEIGHT, 4*TERRAIN_TILE - PLAYER_WIDTH},
+        {"pulo sobe o degrau", 4, columns(20, terrainColumn{ground: GROUND_Y - 16}), 20, 60,
+            true, GROUND_Y - 16 - PLAYER_HEIGHT, 0},
+        {"rampa se sobe andando", 4, slope, 0, 90, true, GROUND_Y - 16 - PLAYER_HEIGHT, 0},
+        {"buraco mata", 4, columns(30, terrainColumn{ground: TERRAIN_PIT}), 0, 120, false, 0, 0},
+        {"plataforma atravessa por baixo", 0, columns(16, terrainColumn{ground: GROUND_Y, platform: GROUND_Y - 20}), 20, 60,
+            true, GROUND_Y - 20 - PLAYER_HEIGHT, 0},
+        {"pulo curto fica embaixo da plataforma", 0, columns(16, terrainColumn{ground: GROUND_Y, platform: GROUND_Y - 20}), 1, 60,
+            true, GROUND_Y - PLAYER_HEIGHT, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            levelTerrain(0)
+            setColumns(tt.from, tt.columns)
+            pl := &players[0]
+            for i := 0; i < tt.frames; i++ {
+                host.gamepads[0] = 0
+                if i < tt.hold {
+                    host.gamepads[0] = BUTTON_1
+                }
+                pl.invuln = PLAYER_INVULN_FRAMES
+                host.step()
+            }
+            if alive := (pl.flags & 0x02) != 0; alive != tt.alive {
+                t.Fatalf("vivo = %v, want %v (x=%d y=%d)", alive, tt.alive, pl.x, pl.y)
+            }
+            if !tt.alive {
+                return
+            }
+            if pl.y != tt.y || (pl.flags&0x01) == 0 {
+                t.Errorf("y = %d no chão=%v, want %d no chão", pl.y, (pl.flags&0x01) != 0, tt.y)
+            }
+            if tt.maxX != 0 && pl.x != tt.maxX {
+                t.Errorf("x = %d, want %d encostado na parede", pl.x, tt.maxX)
+            }
+        })
+    }
+}
+
+func TestLevelChunks(t *testing.T) {
+    for i := range levelChunks {
+        ch := &levelChunks[i]
+        n := len(ch.ground)
+        if n == 0 || chunkHeight(ch.ground, 0) < 0 || chunkHeight(ch.ground, n-1) < 0 {
+            t.Errorf("%s: o trecho precisa começar e terminar com chão", ch.name)
+            continue
+        }
+        if n > CHUNK_MAX_COLUMNS {
+            t.Errorf("%s: %d colunas, o máximo é %d", ch.name, n, CHUNK_MAX_COLUMNS)
+        }
+        if len(ch.platform) > n || len(ch.spawns) > n {
+            t.Errorf("%s: linhas mais compridas que o chão", ch.name)
+        }
+        for col := 0; col < n; col++ {
+            if c := ch.ground[col]; c != ' ' && chunkHeight(ch.ground, col) > TERRAIN_MAX_RISE {
+                t.Errorf("%s: chão '%c' inválido na coluna %d", ch.name, c, col)
+            }
+        }
+        for col := 0; col < len(ch.platform); col++ {
+            if c := ch.platform[col]; c != ' ' && chunkHeight(ch.platform, col) <= 0 {
+                t.Errorf("%s: plataforma '%c' inválida na coluna %d", ch.name, c, col)
+            }
+        }
+        for col := 0; col < len(ch.spawns); col++ {
+            c := ch.spawns[col]
+            if !strings.ContainsRune(" gfrsp", rune(c)) {
+                t.Errorf("%s: entidade '%c' desconhecida na coluna %d", ch.name, c, col)
+            }
+            // Quem fica no chão precisa de chão embaixo
+            if strings.ContainsRune("grs", rune(c)) && chunkHeight(ch.ground, col) < 0 {
+                t.Errorf("%s: '%c' em cima do buraco na coluna %d", ch.name, c, col)
+            }
+        }
+        if ch.weights == [DIFFICULTY_TIERS]uint8{} {
+            t.Errorf("%s: sem peso em nenhuma dificuldade", ch.name)
+        }
+    }
+
+    // Depois de qualquer trecho sempre há com o que continuar
+    for tier := 0; tier < DIFFICULTY_TIERS; tier++ {
+        difficultyTier = int8(tier)
+        for prev := -1; prev < len(levelChunks); prev++ {
+            lastChunk = prev
+            total := int32(0)
+            for i := range levelChunks {
+                total += chunkWeight(i)
+            }
+            if total == 0 {
+                t.Errorf("dificuldade %d: nada cabe depois do trecho %d", tier, prev)
+            }
+        }
+    }
+}
+
+func TestLevelGenerator(t *testing.T) {
+    for seed := uint32(1); seed <= 40; seed++ {
+        setupGame(t)
+        beginRun(seed * 7919)
+        for col := int32(0); col < TERRAIN_SAFE_START/TERRAIN_TILE; col++ {
+            if c := terrainColumnAt(col); c != (terrainColumn{ground: GROUND_Y}) {
+                t.Fatalf("seed %d: largada não é plana e vazia na coluna %d: %+v", seed, col, c)
+            }
+        }
+
+        // Anda a câmera e confere cada coluna nova contra a anterior, em
+        // todas as dificuldades
+        prev, pit := int32(GROUND_Y), 0
+        checked := int32(0)
+        for cameraX = 0; cameraX < 20000; cameraX += 64 {
+            difficultyTier = int8(cameraX / 5000)
+            extendTerrain()
+            for ; checked < terrainEnd; checked++ {
+                c := terrainColumnAt(checked)
+                if c.ground == TERRAIN_PIT {
+                    // Buraco sem plataforma por cima só até três colunas
+                    pit++
+                    if c.platform != 0 {
+                        pit = 0
+                    }
+                    if pit > 3 {
+                        t.Fatalf("seed %d: buraco largo demais na coluna %d", seed, checked)
+                    }
+                    continue
+                }
+                pit = 0
+                y := int32(c.ground)
+                if y > GROUND_Y || y < GROUND_Y-TERRAIN_MAX_RISE {
+                    t.Fatalf("seed %d: chão em %d fora da faixa na coluna %d", seed, y, checked)
+                }
+                // Descer é livre; subir, no máximo um degrau
+                if prev-y > TERRAIN_LEDGE {
+                    t.Fatalf("seed %d: degrau de %d na coluna %d", seed, prev-y, checked)
+                }
+                prev = y + int32(c.slope)
+            }
+        }
+    }
+}
+
+func TestReleaseSpawns(t *testing.T) {
+    tests := []struct {
+        name string
+        column terrainColumn
+        kind int8
+        x, y int32 // Relativo ao começo da coluna
+    }{
+        {"pedra no chão", terrainColumn{ground: GROUND_Y, spawn: 'r'}, KIND_ROCK, 0, GROUND_Y - 8},
+        {"espeto no degrau", terrainColumn{ground: GROUND_Y - 16, spawn: 's'}, KIND_SPIKE, 1, GROUND_Y - 24},
+        {"terrestre", terrainColumn{ground: GROUND_Y - 8, spawn: 'g'}, KIND_GROUND_ENEMY, 0, GROUND_Y - 20},
+        {"cápsula no pulo", terrainColumn{ground: GROUND_Y, spawn: 'p'}, KIND_PICKUP, 0, GROUND_Y - 30},
+        {"cápsula na plataforma", terrainColumn{ground: TERRAIN_PIT, platform: GROUND_Y - 24, spawn: 'p'}, KIND_PICKUP, 0,
+            GROUND_Y - 24 - SPRITE_PICKUP_HEIGHT - 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            clearEntities()
+            levelTerrain(0)
+            releaseSpawns() // Alcança a borda da tela
+            col := spawnColumn + 1
+            setColumns(col, []terrainColumn{tt.column})
+
+            // Ainda longe da tela: nada aparece
+            releaseSpawns()
+            if n := len(activeOfKind(tt.kind)); n != 0 {
+                t.Fatalf("%d entidades antes da coluna chegar perto da tela", n)
+            }
+            cameraX += 2 * TERRAIN_TILE
+            releaseSpawns()
+            slots := activeOfKind(tt.kind)
+            if len(slots) != 1 {
+                t.Fatalf("entidades = %d, want 1", len(slots))
+            }
+            if e := &entities[slots[0]]; e.x != col*TERRAIN_TILE+tt.x || e.y != tt.y {
+                t.Errorf("posição = (%d, %d), want (%d, %d)", e.x, e.y, col*TERRAIN_TILE+tt.x, tt.y)
+            }
+        })
+    }
+}
+
+func TestTerrainPassable(t *testing.T) {
+    flat := terrainColumn{ground: GROUND_Y}
+    pit := terrainColumn{ground: TERRAIN_PIT}
+    spike := terrainColumn{ground: GROUND_Y, spawn: 's'}
+    // Pista, o obstáculo e chão plano até o fim
+    chunk := func(count int, c terrainColumn) []terrainColumn {
+        return append(append(columns(4, flat), columns(count, c)...), columns(8, flat)...)
+    }
+
+    tests := []struct {
+        name string
+        score int32 // Define a velocidade e o pulo
+        columns []terrainColumn
+        want bool
+    }{
+        {"plano", 0, columns(12, flat), true},
+        {"pedra se pula", 0, chunk(1, terrainColumn{ground: GROUND_Y, spawn: 'r'}), true},
+        {"buraco de três colunas", 0, chunk(3, pit), true},
+        {"buraco de quatro colunas é longe demais no começo", 0, chunk(4, pit), false},
+        {"buraco de quatro colunas no ritmo máximo", DIFFICULTY_RAMP_SCORE, chunk(4, pit), true},
+        {"três espetos seguidos", 0, chunk(3, spike), false},
+        {"um espeto", 0, chunk(1, spike), true},
+        {"terrestre: pula-se o tiro", 0, chunk(1, terrainColumn{ground: GROUND_Y, spawn: 'g'}), true},
+        // Sem chão entre o espeto e a faixa do tiro para pousar e pular de novo
+        {"espeto sob o começo do tiro do terrestre", 0,
+            append(append(columns(4, flat), spike, flat, terrainColumn{ground: GROUND_Y, spawn: 'g'}), columns(8, flat)...), false},
+        {"degrau mais alto que o pulo", 0, append(columns(4, flat), columns(8, terrainColumn{ground: GROUND_Y - 64})...), false},
+        {"degrau alcançável", 0, append(columns(4, flat), columns(8, terrainColumn{ground: GROUND_Y - 24})...), true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            score = tt.score
+            updateSpeeds()
+            levelTerrain(0)
+            from := terrainEnd
+            for _, c := range tt.columns {
+                addColumn(c)
+            }
+            if got := terrainPassable(from); got != tt.want {
+                t.Errorf("terrainPassable = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSpawnChunkRepair(t *testing.T) {
+    setupGame(t)
+    levelTerrain(0)
+    resetLevel()
+    // Sem chão antes da emenda nenhum trecho tem por onde começar
+    for i := 0; i < VALIDATE_JOIN; i++ {
+        addColumn(terrainColumn{ground: TERRAIN_PIT})
+    }
+    from := terrainEnd
+    spawnChunk()
+
+    if levelStats.repairs != 1 || lastChunk != -1 {
+        t.Fatalf("consertos = %d, lastChunk = %d; want 1 e -1", levelStats.repairs, lastChunk)
+    }
+    if terrainEnd != from+REPAIR_COLUMNS {
+        t.Fatalf("colunas novas = %d, want %d", terrainEnd-from, REPAIR_COLUMNS)
+    }
+    for col := from; col < terrainEnd; col++ {
+        if c := terrainColumnAt(col); c != (terrainColumn{ground: GROUND_Y}) {
+            t.Errorf("coluna %d = %+v, want chão plano", col, c)
+        }
+    }
+    rejected := int32(0)
+    for i := range levelChunks {
+        rejected += levelStats.rejected[i]
+        if levelStats.placed[i] != 0 {
+            t.Errorf("%s emendado sem passagem", levelChunks[i].name)
+        }
+    }
+    if rejected == 0 {
+        t.Error("nenhum trecho recusado antes do conserto")
+    }
+}
+
+func TestRejectedChunkRing(t *testing.T) {
+    setupGame(t)
+    levelTerrain(0)
+    resetLevel()
+    // Buffer cheio de colunas marcadas, para o trecho recusado escrever
+    // por cima das mais antigas
+    for i := 0; i < TERRAIN_COLUMNS-VALIDATE_JOIN; i++ {
+        addColumn(terrainColumn{ground: GROUND_Y, platform: int16(i % 40)})
+    }
+    for i := 0; i < VALIDATE_JOIN; i++ {
+        addColumn(terrainColumn{ground: TERRAIN_PIT})
+    }
+    from := terrainEnd
+    before := terrain
+    spawnChunk()
+
+    if levelStats.repairs != 1 {
+        t.Fatalf("consertos = %d, want 1", levelStats.repairs)
+    }
+    for col := terrainEnd - TERRAIN_COLUMNS; col < from; col++ {
+        if c, want := terrainColumnAt(col), before[col%TERRAIN_COLUMNS]; c != want {
+            t.Errorf("coluna %d = %+v depois da recusa, want %+v", col, c, want)
+        }
+    }
+}
+
+// Colunas e entidades que um desafio gera até a distância dada. step é
+// quanto a câmera anda por quadro e points, a pontuação a cada passo: nada
+// disso pode mudar a fase
+func challengeLevel(t *testing.T, code uint32, step, points int32) ([]terrainColumn, []entity) {
+    setupGame(t)
+    startChallenge(code)
+    var spawned []entity
+    for cameraX = 0; cameraX < 12000; cameraX += step {
+        score += points
+        updateSpeeds()
+        clearEntities()
+        proceduralSpawn()
+        for i := 0; i < MAX_ENTITIES; i++ {
+            if e := entities[i]; e.active {
+                spawned = append(spawned, entity{kind: e.kind, x: e.x, y: e.y, variant: e.variant})
+            }
+        }
+    }
+    var cols []terrainColumn
+    for col := int32(0); col < spawnColumn; col++ {
+        cols = append(cols, terrainColumnAt(col))
+    }
+    return cols, spawned
+}
+
+func TestChallengeCode(t *testing.T) {
+    code, ok := parseCode("dayz2")
+    if !ok {
+        t.Fatal("código válido recusado")
+    }
+    text := ""
+    for i := int32(0); i < CODE_LENGTH; i++ {
+        text += string(codeAlphabet[codeSymbol(code, i)])
+    }
+    if text != "DAYZ2" {
+        t.Errorf("código = %q, want DAYZ2", text)
+    }
+    for _, bad := range []string{"", "DAYZ", "DAYZ22", "DAY01"} {
+        if _, ok := parseCode(bad); ok {
+            t.Errorf("%q aceito como código", bad)
+        }
+    }
+    if setCodeSymbol(code, 4, CODE_NONE) != code|(1<<CODE_BITS-1) {
+        t.Error("setCodeSymbol não ficou na própria letra")
+    }
+
+    // Mesmo código, jogos diferentes: mesma fase e mesmas entidades
+    colsA, spawnsA := challengeLevel(t, code, 1, 0)
+    colsB, spawnsB := challengeLevel(t, code, 3, 2)
+    if len(colsA) != len(colsB) || len(spawnsA) != len(spawnsB) || len(spawnsA) == 0 {
+        t.Fatalf("colunas %d e %d, entidades %d e %d", len(colsA), len(colsB), len(spawnsA), len(spawnsB))
+    }
+    for i := range colsA {
+        if colsA[i] != colsB[i] {
+            t.Fatalf("coluna %d: %+v e %+v", i, colsA[i], colsB[i])
+        }
+    }
+    for i := range spawnsA {
+        if spawnsA[i] != spawnsB[i] {
+            t.Fatalf("entidade %d: %+v e %+v", i, spawnsA[i], spawnsB[i])
+        }
+    }
+
+    // Outro código, outra fase
+    colsC, _ := challengeLevel(t, code+1, 1, 0)
+    same := true
+    for i := range colsC {
+        if i < len(colsA) && colsC[i] != colsA[i] {
+            same = false
+        }
+    }
+    if same {
+        t.Error("códigos vizinhos geraram a mesma fase")
+    }
+}
+
+func TestChallengeRecord(t *testing.T) {
+    setupGame(t)
+    highScore = 70
+    for i, code := range []uint32{1, 2, 1} {
+        startChallenge(code)
+        score = int32(100 - i*40) // 100, 60, 20
+        recordRun()
+    }
+    if highScore != 70 {
+        t.Errorf("recorde normal = %d, want 70 (desafio não conta)", highScore)
+    }
+    if challengeBests[0].code != 1 || challengeBests[0].best != 100 ||
+       challengeBests[1].code != 2 || challengeBests[1].best != 60 {
+        t.Fatalf("desafios = %+v, want o 1 (100) e depois o 2 (60)", challengeBests[:2])
+    }
+
+    // Sobrevive ao disco, e a lista cheia esquece o jogado há mais tempo (o 2)
+    for code := uint32(10); code < 10+SAVE_CHALLENGES-1; code++ {
+        recordChallenge(code, int32(code))
+    }
+    writeSave()
+    loadSave()
+    if challengeBest(1) != 100 || challengeBest(2) != 0 || challengeBest(10) != 10 || lastChallengeCode() != 16 {
+        t.Errorf("depois de carregar: 1=%d 2=%d 10=%d último=%d", challengeBest(1), challengeBest(2),
+            challengeBest(10), lastChallengeCode())
+    }
+
+    // Registro da versão 1 ainda carrega, sem desafios
+    v1 := make([]uint8, SAVE_V1_SIZE)
+    copy(v1, saveMagic[:])
+    v1[3] = 1
+    putU32(v1, 4, 123)
+    putU16(v1, SAVE_V1_SIZE-SAVE_CHECKSUM_SIZE, checksum(v1[:SAVE_V1_SIZE-SAVE_CHECKSUM_SIZE]))
+    host.diskSize = uint32(copy(host.disk[:], v1))
+    loadSave()
+    if highScore != 123 || lastChallengeCode() != 0 || challengeBest(2) != 0 {
+        t.Errorf("versão 1: recorde %d, último desafio %d", highScore, lastChallengeCode())
+    }
+}
+
+func TestPCG32(t *testing.T) {
+    // Saída de referência do pcg32-demo (seed 42, stream 54)
+    var r pcg32
+    r.seed(42, 54)
+    want := []uint32{0xa15c02b7, 0x7b47f409, 0xba1d3330, 0x83d2f293, 0xbfa4784b, 0xcbed606e}
+    for i, w := range want {
+        if got := r.next(); got != w {
+            t.Fatalf("saída %d = %#x, want %#x", i, got, w)
+        }
+    }
+
+    // Qui-quadrado das faixas; o limite é o valor crítico de p = 0,001
+    tests := []struct {
+        bound uint32
+        critical float64
+    }{
+        {2, 10.83},
+        {6, 20.52},
+        {100, 148.2},
+        {COLUMN_ROLLS, 386.8},
+    }
+    for _, tt := range tests {
+        r.seed(7, STREAM_LEVEL)
+        const perBucket = 400
+        counts := make([]int, tt.bound)
+        for i := 0; i < int(tt.bound)*perBucket; i++ {
+            v := r.bounded(tt.bound)
+            if v >= tt.bound {
+                t.Fatalf("bounded(%d) = %d", tt.bound, v)
+            }
+            counts[v]++
+        }
+        chi := 0.0
+        for _, c := range counts {
+            d := float64(c - perBucket)
+            chi += d * d / perBucket
+        }
+        if chi > tt.critical {
+            t.Errorf("bounded(%d): qui-quadrado %.1f > %.1f", tt.bound, chi, tt.critical)
+        }
+    }
+
+    // Sem viés mesmo com uma faixa que não divide 2^32: com módulo simples,
+    // o primeiro terço sairia metade das vezes
+    r.seed(7, STREAM_AI)
+    const n = 30000
+    low := 0
+    for i := 0; i < n; i++ {
+        if r.bounded(3<<30) < 1<<30 {
+            low++
+        }
+    }
+    if low < n*31/100 || low > n*35/100 {
+        t.Errorf("primeiro terço saiu %d de %d vezes, want ~1/3", low, n)
+    }
+
+    // Pares seguidos espalhados pela grade 16x16
+    r.seed(7, STREAM_COSMETIC)
+    var grid [256]int
+    const pairs = 256 * 100
+    for i := 0; i < pairs; i++ {
+        grid[r.next()>>28<<4|r.next()>>28]++
+    }
+    chi := 0.0
+    for _, c := range grid {
+        d := float64(c - 100)
+        chi += d * d / 100
+    }
+    if chi > 330.5 {
+        t.Errorf("pares seguidos: qui-quadrado %.1f > 330.5", chi)
+    }
+
+    // Streams com a mesma seed não andam juntos: bits iguais ~metade
+    var a, b pcg32
+    a.seed(12345, STREAM_LEVEL)
+    b.seed(12345, STREAM_AI)
+    same := 0
+    for i := 0; i < 1000; i++ {
+        x := a.next() ^ b.next()
+        for ; x != 0; x &= x - 1 {
+            same--
+        }
+        same += 32
+    }
+    if same < 15500 || same > 16500 {
+        t.Errorf("%d de 32000 bits iguais entre streams, want ~16000", same)
+    }
+}
+
+// A fase só depende da seed: sorteios da IA e dos efeitos não a mudam
+func TestRandomStreams(t *testing.T) {
+    level := func(noise bool) []terrainColumn {
+        setupGame(t)
+        beginRun(777)
+        // Guarda cada coluna logo que é emendada, antes de sair do buffer
+        var cols []terrainColumn
+        for cameraX = 0; cameraX < 8000; cameraX += 40 {
+            if noise {
+                aiRand.next()
+                cosmeticRand.intn(5)
+            }
+            extendTerrain()
+            if int32(len(cols)) < terrainEnd-TERRAIN_COLUMNS {
+                t.Fatalf("colunas %d a %d saíram do buffer sem ser conferidas", len(cols), terrainEnd-TERRAIN_COLUMNS)
+            }
+            for col := int32(len(cols)); col < terrainEnd; col++ {
+                cols = append(cols, terrainColumnAt(col))
+            }
+        }
+        return cols
+    }
+    quiet, noisy := level(false), level(true)
+    if len(quiet) != len(noisy) {
+        t.Fatalf("colunas %d e %d", len(quiet), len(noisy))
+    }
+    for i := range quiet {
+        if quiet[i] != noisy[i] {
+            t.Fatalf("coluna %d mudou com sorteios de outros streams", i)
+        }
+    }
+}
+
+func TestEnemyAI(t *testing.T) {
+    const playerX = 40
+    tests := []struct {
+        name string
+        kind int8
+        x, y int32
+        aim int8
+        rock int32 // x da pedra, ou 0
+        frames int
+        check func(t *testing.T, e *entity)
+    }{
+        {"patrulha longe do jogador", KIND_GROUND_ENEMY, 200, GROUND_Y - 12, AIM_UP, 0, 5, func(t *testing.T, e *entity) {
+            if e.aiState != AI_PATROL || e.velX != -currentEnemySpeed {
+                t.Errorf("estado %d velX %d, want patrulha a %d", e.aiState, e.velX, -currentEnemySpeed)
+            }
+        }},
+        {"se aproxima mais depressa", KIND_GROUND_ENEMY, 120, GROUND_Y - 12, AIM_UP, 0, 5, func(t *testing.T, e *entity) {
+            if e.aiState != AI_APPROACH || e.velX >= -currentEnemySpeed {
+                t.Errorf("estado %d velX %d, want aproximação mais rápida que %d", e.aiState, e.velX, -currentEnemySpeed)
+            }
+        }},
+        {"para na distância", KIND_GROUND_ENEMY, 120, GROUND_Y - 12, AIM_UP, 0, 120, func(t *testing.T, e *entity) {
+            want := int32(playerX + PLAYER_WIDTH + 28)
+            if e.velX != 0 || e.x != want {
+                t.Errorf("x %d velX %d, want parado em %d", e.x, e.velX, want)
+            }
+        }},
+        {"recua da mira", KIND_GROUND_ENEMY, 100, GROUND_Y - 12, AIM_RIGHT, 0, 3, func(t *testing.T, e *entity) {
+            if e.aiState != AI_RETREAT || e.velX <= 0 {
+                t.Errorf("estado %d velX %d, want recuo", e.aiState, e.velX)
+            }
+        }},
+        {"se esconde atrás da pedra", KIND_GROUND_ENEMY, 110, GROUND_Y - 12, AIM_RIGHT, 80, 40, func(t *testing.T, e *entity) {
+            if e.aiState != AI_COVER || e.x != 80+8+1 || e.velX != 0 {
+                t.Fatalf("estado %d x %d velX %d, want escondido em 89", e.aiState, e.x, e.velX)
+            }
+            spawnEntity(KIND_BULLET, e.x-4, e.y+4, BULLET_SPEED, 0)
+            updateEntities()
+            checkCollisions()
+            // O slot de quem morre vira partícula da explosão
+            if !e.active || e.kind != KIND_GROUND_ENEMY || e.hp != entityKinds[e.kind].hp {
+                t.Errorf("tiro reto acertou quem está escondido")
+            }
+        }},
+        {"blindado não foge", KIND_HEAVY_ENEMY, 100, GROUND_Y - 12, AIM_RIGHT, 0, 3, func(t *testing.T, e *entity) {
+            if e.aiState != AI_APPROACH || e.velX >= 0 {
+                t.Errorf("estado %d velX %d, want avanço", e.aiState, e.velX)
+            }
+        }},
+        {"voador mergulha e sobe", KIND_FLYING_ENEMY, playerX, 60, AIM_UP_LEFT, 0, 1, func(t *testing.T, e *entity) {
+            if e.aiState != AI_DIVE || e.velY <= 0 {
+                t.Fatalf("estado %d velY %d, want mergulho", e.aiState, e.velY)
+            }
+            lowest := e.y
+            for i := 0; i < AI_DIVE_FRAMES && e.aiState == AI_DIVE; i++ {
+                updateEntities()
+                lowest = max32(lowest, e.y)
+            }
+            if lowest < GROUND_Y-PLAYER_HEIGHT-2 {
+                t.Errorf("desceu só até y %d", lowest)
+            }
+            if e.aiState == AI_DIVE || e.y > AI_FLY_TOP {
+                t.Errorf("estado %d y %d, want de volta ao alto", e.aiState, e.y)
+            }
+        }},
+        {"voador com escudo paira à frente", KIND_SHIELDED_ENEMY, 100, 60, AIM_UP, 0, 60, func(t *testing.T, e *entity) {
+            if e.aiState != AI_APPROACH || e.x != playerX+40 || e.y != GROUND_Y-PLAYER_HEIGHT-AI_HOVER {
+                t.Errorf("estado %d em (%d, %d), want pairando em (%d, %d)", e.aiState, e.x, e.y,
+                    playerX+40, GROUND_Y-PLAYER_HEIGHT-AI_HOVER)
+            }
+        }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            clearEntities()
+            levelTerrain(0)
+            players[0].x, players[0].y = playerX, GROUND_Y-PLAYER_HEIGHT
+            players[0].aimDirection = tt.aim
+            if tt.rock != 0 {
+                spawnEntity(KIND_ROCK, tt.rock, GROUND_Y-8, 0, 0)
+            }
+            e := &entities[spawnEntity(tt.kind, tt.x, tt.y, 0, 0)]
+            for i := 0; i < tt.frames; i++ {
+                updateEntities()
+            }
+            tt.check(t, e)
+        })
+    }
+}
+
+// O terrestre pula buracos de até três colunas; o blindado cai
+func TestEnemyHop(t *testing.T) {
+    tests := []struct {
+        name string
+        kind int8
+        pit int
+        lands bool
+    }{
+        {"buraco de duas colunas", KIND_GROUND_ENEMY, 2, true},
+        {"buraco de três colunas", KIND_GROUND_ENEMY, 3, true},
+        {"blindado cai", KIND_HEAVY_ENEMY, 2, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            setupGame(t)
+            clearEntities()
+            levelTerrain(0)
+            players[0].flags &^= 0x02 // Sem jogador à vista: só patrulha
+            col := cameraX/TERRAIN_TILE + 10
+            setColumns(col, columns(tt.pit, terrainColumn{ground: TERRAIN_PIT}))
+            pitX := col * TERRAIN_TILE
+            e := &entities[spawnEntity(tt.kind, pitX+int32(tt.pit)*TERRAIN_TILE+4, GROUND_Y-12, 0, 0)]
+            arc := hopArc(e, 60)
+            landed := e.active && e.x+int32(e.width) <= pitX && e.y == GROUND_Y-12
+            if landed != tt.lands {
+                t.Errorf("pousou = %v (x %d, y %d, ativo %v), want %v", landed, e.x, e.y, e.active, tt.lands)
+            }
+            if tt.lands {
+                checkHopArc(t, e, arc)
+            }
+        })
+    }
+}
+
+// Pulo sobre um tiro no chão plano: sobe e desce sem saltos e, ao pousar,
+// volta a patrulhar
+func TestEnemyDodgeHop(t *testing.T) {
+    setupGame(t)
+    clearEntities()
+    levelTerrain(0)
+    players[0].flags &^= 0x02
+    defer func(dodge int8) { enemyBehaviors[KIND_GROUND_ENEMY].dodge = dodge }(enemyBehaviors[KIND_GROUND_ENEMY].dodge)
+    enemyBehaviors[KIND_GROUND_ENEMY].dodge = 100
+
+    x := cameraX + 100
+    e := &entities[spawnEntity(KIND_GROUND_ENEMY, x, GROUND_Y-12, 0, 0)]
+    spawnEntity(KIND_BULLET, x-AI_DODGE_RANGE+4-BULLET_WIDTH, GROUND_Y-8, BULLET_SPEED, 0)
+    arc := hopArc(e, 40)
+    if e.y != GROUND_Y-12 {
+        t.Fatalf("y = %d depois do pulo, want %d", e.y, GROUND_Y-12)
+    }
+    checkHopArc(t, e, arc)
+    if e.velX != -aiSpeed(enemyBehaviors[KIND_GROUND_ENEMY].patrol) {
+        t.Errorf("velX = %d depois do pulo, want a da patrulha", e.velX)
+    }
+}
+
+// Alturas de e a cada quadro enquanto estiver ativo
+func hopArc(e *entity, frames int) []int32 {
+    arc := []int32{e.y}
+    for i := 0; i < frames && e.active; i++ {
+        updateEntities()
+        arc = append(arc, e.y)
+    }
+    return arc
+}
+
+// O pulo sobe de verdade, não muda de altura aos trancos e termina fora de
+// AI_HOP
+func checkHopArc(t *testing.T, e *entity, arc []int32) {
+    t.Helper()
+    top := arc[0]
+    for i := 1; i < len(arc); i++ {
+        if arc[i] < top {
+            top = arc[i]
+        }
+        if d := arc[i] - arc[i-1]; d > 6 || d < -6 {
+            t.Fatalf("quadro %d: y %d -> %d", i, arc[i-1], arc[i])
+        }
+    }
+    if arc[0]-top < 8 {
+        t.Errorf("subiu só %d pixels", arc[0]-top)
+    }
+    if e.aiState == AI_HOP {
+        t.Errorf("continua em AI_HOP depois de pousar")
+    }
+}
@@ -0,0 +1,154 @@
+package main
+
+// Mira pelo mouse. O botão direito atira do jogador 1 na direção do cursor,
+// com a velocidade normalizada em ponto fixo (o tiro não fica preso às oito
+// direções do direcional). A arma vira para o octante mais próximo.
+//
+// A mira desenhada e a arma seguindo o cursor são só visuais e usam o mouse
+// ao vivo; a simulação só vê a posição gravada no quadro do clique
+// (frameMouseX/Y), para o replay reproduzir o tiro.
+const (
+    MOUSE_SHOT_SPEED = BULLET_SPEED
+    CROSSHAIR_GAP = 2 // Pixels vazios entre o centro e cada braço
+)
+
+var (
+    mouseAimActive bool = false // Cursor em uso; o direcional desliga
+    lastMouseX, lastMouseY int16
+)
+
+// Cursor preso à tela
+func mouseScreenPosition() (uint8, uint8) {
+    x, y := input.MousePosition()
+    return uint8(clamp32(int32(x), 0, SCREEN_WIDTH-1)), uint8(clamp32(int32(y), 0, SCREEN_HEIGHT-1))
+}
+
+func clamp32(v, min, max int32) int32 {
+    if v < min {
+        return min
+    }
+    if v > max {
+        return max
+    }
+    return v
+}
+
+// Liga a mira quando o mouse se mexe e desliga quando o jogador 1 usa o
+// direcional. Roda todo quadro e não mexe na simulação
+func updateMouseAim() {
+    x, y := input.MousePosition()
+    if x != lastMouseX || y != lastMouseY {
+        mouseAimActive = true
+    }
+    lastMouseX, lastMouseY = x, y
+    if input.Gamepad(0)&(BUTTON_LEFT|BUTTON_RIGHT|BUTTON_UP|BUTTON_DOWN) != 0 ||
+       input.Netplay()&NETPLAY_ACTIVE != 0 {
+        mouseAimActive = false
+    }
+}
+
+// Octante e vetor da arma do jogador até o alvo (coordenadas do mundo).
+// No chão o tiro não desce; com o alvo em cima do jogador, devolve -1
+func cursorAim(p int, targetX, targetY int32) (int8, int32, int32) {
+    pl := &players[p]
+    dx := targetX - (pl.x + PLAYER_WIDTH/2)
+    dy := targetY - (pl.y + 4)
+    if (pl.flags&0x01) != 0 && dy > 0 {
+        dy = 0
+    }
+    return octantOf(dx, dy), dx, dy
+}
+
+// Direção mais próxima entre as oito, ou -1 para o vetor nulo
+func octantOf(dx, dy int32) int8 {
+    sx, sy := sign32(dx), sign32(dy)
+    absX, absY := dx*sx, dy*sy
+    if absX > 2*absY {
+        sy = 0
+    } else if absY > 2*absX {
+        sx = 0
+    }
+    return aimFromPad[sy+1][sx+1]
+}
+
+func sign32(v int32) int32 {
+    if v > 0 {
+        return 1
+    }
+    if v < 0 {
+        return -1
+    }
+    return 0
+}
+
+// Vetor (dx, dy) redimensionado para ter módulo speed (ponto fixo 8.8).
+// O comprimento é calculado em 1/256 de pixel para não perder a direção de
+// vetores curtos
+func aimVector(dx, dy, speed int32) (int16, int16) {
+    length := isqrt((int64(dx)*int64(dx) + int64(dy)*int64(dy)) << (2 * FIXED_SHIFT))
+    if length == 0 {
+        return int16(speed), 0
+    }
+    scale := int64(speed) << FIXED_SHIFT
+    return int16(roundDiv(int64(dx)*scale, length)), int16(roundDiv(int64(dy)*scale, length))
+}
+
+// Raiz quadrada inteira (arredondada para baixo), pelo método de Newton
+func isqrt(n int64) int64 {
+    if n <= 0 {
+        return 0
+    }
+    x := n
+    y := (x + 1) / 2
+    for y < x {
+        x = y
+        y = (x + n/x) / 2
+    }
+    return x
+}
+
+// a/b arredondado ao inteiro mais próximo; b > 0
+func roundDiv(a, b int64) int64 {
+    if a < 0 {
+        return -((-a + b/2) / b)
+    }
+    return (a + b/2) / b
+}
+
+// Tiro do jogador na direção do alvo
+func shootAt(p int, targetX, targetY int32) {
+    aim, dx, dy := cursorAim(p, targetX, targetY)
+    if aim < 0 {
+        shoot(p) // Cursor em cima do jogador: tiro na mira atual
+        return
+    }
+    players[p].aimDirection = aim
+    velX, velY := aimVector(dx, dy, MOUSE_SHOT_SPEED)
+    fire(p, velX, velY)
+}
+
+// Direção em que a arma é desenhada: segue o cursor ao vivo na mira pelo
+// mouse, fora do replay
+func weaponAim(p int) int8 {
+    if p == 0 && mouseAimActive && replayMode != REPLAY_PLAYING {
+        x, y := mouseScreenPosition()
+        if aim, _, _ := cursorAim(0, int32(x)+cameraX, int32(y)); aim >= 0 {
+            return aim
+        }
+    }
+    return players[p].aimDirection
+}
+
+func drawCrosshair() {
+    if !mouseAimActive || replayMode == REPLAY_PLAYING {
+        return
+    }
+    mx, my := mouseScreenPosition()
+    x, y := int32(mx), int32(my)
+    setColors(0x04)
+    rect(x, y, 1, 1)
+    rect(x-CROSSHAIR_GAP-2, y, 2, 1)
+    rect(x+CROSSHAIR_GAP+1, y, 2, 1)
+    rect(x, y-CROSSHAIR_GAP-2, 1, 2)
+    rect(x, y+CROSSHAIR_GAP+1, 1, 2)
+}
@@ -0,0 +1,178 @@
+package main
+
+// Sequenciador de música estilo tracker.
+//
+// Cada padrão é uma lista de pares (nota, duração em steps): a nota segue a
+// numeração MIDI (60 = C4) e 0 é pausa. Cada música tem uma lista de padrões
+// por canal (PULSE1, PULSE2, TRIANGLE) que tocam de forma independente; o
+// ruído fica só para os efeitos. Um step dura stepFrames quadros de update().
+const (
+    MUSIC_CHANNELS = 3
+    MUSIC_MIN_STEP_FRAMES = 4
+
+    // Músicas
+    SONG_NONE = -1
+    SONG_MENU = 0
+    SONG_PLAYING = 1
+    SONG_GAME_OVER = 2
+    SONG_COUNT = 3
+
+    // Nota de pausa
+    REST = 0
+)
+
+type song struct {
+    stepFrames uint8
+    loop bool
+    followsDifficulty bool // Acelera com os níveis de updateSpeeds()
+    tracks [MUSIC_CHANNELS][]uint8 // Índices em musicPatterns
+}
+
+var musicPatterns = [...][]uint8{
+    // 0: menu - arpejo
+    {69, 2, 72, 2, 76, 2, 81, 2, 79, 2, 76, 2, 74, 2, 76, 2},
+    // 1: menu - baixo
+    {45, 4, 41, 4, 48, 4, 43, 4},
+    // 2: menu - harmonia
+    {57, 8, 55, 8},
+    // 3: jogo - melodia A
+    {76, 1, 76, 1, REST, 1, 76, 1, REST, 1, 72, 1, 76, 2, 79, 2, REST, 2, 67, 2, REST, 2},
+    // 4: jogo - melodia B
+    {72, 3, 67, 3, 64, 2, 69, 2, 71, 2, 70, 1, 69, 1, 67, 2},
+    // 5: jogo - baixo
+    {40, 2, 52, 2, 40, 2, 52, 2, 36, 2, 48, 2, 38, 2, 50, 2},
+    // 6: jogo - contratempo
+    {REST, 4, 59, 4, REST, 4, 57, 4},
+    // 7: game over - melodia
+    {67, 4, 64, 4, 60, 4, 55, 8},
+    // 8: game over - baixo
+    {48, 4, 45, 4, 41, 4, 36, 8},
+}
+
+var songs = [SONG_COUNT]song{
+    SONG_MENU: {10, true, false, [MUSIC_CHANNELS][]uint8{{0}, {2}, {1}}},
+    SONG_PLAYING: {8, true, true, [MUSIC_CHANNELS][]uint8{{3, 3, 4, 3}, {6}, {5}}},
+    SONG_GAME_OVER: {9, false, false, [MUSIC_CHANNELS][]uint8{{7}, {}, {8}}},
+}
+
+// Volume e duty cycle de cada canal da música
+var musicVoices = [MUSIC_CHANNELS]struct {
+    volume uint8
+    mode   uint8
+}{
+    {25, TONE_MODE2},
+    {15, TONE_MODE1},
+    {35, 0},
+}
+
+var (
+    currentSong int8 = SONG_NONE
+    musicTimer uint8 = 0
+    musicChannels [MUSIC_CHANNELS]struct {
+        order uint8 // Posição na lista de padrões
+        pos   uint8 // Próximo byte do padrão
+        left  uint8 // Steps restantes da nota atual
+        done  bool
+    }
+)
+
+// Música de cada estado do jogo
+func songForState(state int8) int8 {
+    switch state {
+    case STATE_MENU:
+        return SONG_MENU
+    case STATE_PLAYING, STATE_PAUSED:
+        return SONG_PLAYING
+    case STATE_GAME_OVER:
+        return SONG_GAME_OVER
+    }
+    return SONG_NONE
+}
+
+func startSong(id int8) {
+    currentSong = id
+    musicTimer = 0
+    for i := 0; i < MUSIC_CHANNELS; i++ {
+        musicChannels[i].order = 0
+        musicChannels[i].pos = 0
+        musicChannels[i].left = 0
+        musicChannels[i].done = false
+    }
+}
+
+// Quadros por step, considerando a dificuldade atual
+func musicStepFrames(s *song) uint8 {
+    frames := s.stepFrames
+    if s.followsDifficulty {
+        frames -= uint8(difficultyTier)
+    }
+    if frames < MUSIC_MIN_STEP_FRAMES {
+        frames = MUSIC_MIN_STEP_FRAMES
+    }
+    return frames
+}
+
+// Avança o sequenciador (uma vez por quadro)
+func updateMusic() {
+    id := songForState(gameState)
+    if id != currentSong {
+        startSong(id)
+    }
+    if currentSong == SONG_NONE || gameState == STATE_PAUSED {
+        return // Na pausa a música fica parada onde estava
+    }
+
+    if musicTimer > 0 {
+        musicTimer--
+        return
+    }
+    s := &songs[currentSong]
+    stepFrames := musicStepFrames(s)
+    musicTimer = stepFrames - 1
+
+    for ch := 0; ch < MUSIC_CHANNELS; ch++ {
+        stepMusicChannel(s, ch, stepFrames)
+    }
+}
+
+func stepMusicChannel(s *song, ch int, stepFrames uint8) {
+    c := &musicChannels[ch]
+    if c.done {
+        return
+    }
+    if c.left > 1 {
+        c.left-- // Nota atual ainda soando
+        return
+    }
+
+    track := s.tracks[ch]
+    if len(track) == 0 {
+        c.done = true
+        return
+    }
+    pattern := musicPatterns[track[c.order]]
+    if int(c.pos) >= len(pattern) {
+        c.pos = 0
+        c.order++
+        if int(c.order) >= len(track) {
+            if !s.loop {
+                c.done = true
+                return
+            }
+            c.order = 0
+        }
+        pattern = musicPatterns[track[c.order]]
+    }
+
+    note := pattern[c.pos]
+    c.left = pattern[c.pos+1]
+    c.pos += 2
+
+    // Canal cedido a um efeito sonoro: a nota é pulada
+    if note == REST || sfxBusy(ch) || settings&SETTING_MUTE_MUSIC != 0 {
+        return
+    }
+    voice := &musicVoices[ch]
+    sustain := uint32(c.left)*uint32(stepFrames) - 1
+    sound.Tone(uint32(note), sustain, uint32(voice.volume), uint32(ch)|uint32(voice.mode)|TONE_NOTE_MODE)
+}
@@ -0,0 +1,161 @@
+package main
+
+// Menu de pausa. Qualquer jogador pausa com BAIXO+BOTÃO 2 (ou botão do meio
+// do mouse); a simulação fica congelada e os quadros pausados não entram no
+// replay, então a partida continua determinística. Dali também se liga e
+// desliga o som (settings, gravado no perfil na hora).
+const (
+    MOUSE_MIDDLE = 4
+
+    // Opções do menu de pausa
+    PAUSE_RESUME = 0
+    PAUSE_RESTART = 1
+    PAUSE_SFX = 2
+    PAUSE_MUSIC = 3
+    PAUSE_QUIT = 4
+    PAUSE_OPTIONS = 5
+)
+
+var pauseLabels = [PAUSE_OPTIONS]string{"RESUME", "RESTART", "SFX", "MUSIC", "QUIT TO MENU"}
+
+// Bit de settings que cada opção liga e desliga; 0 nas outras
+var pauseSettings = [PAUSE_OPTIONS]uint8{PAUSE_SFX: SETTING_MUTE_SFX, PAUSE_MUSIC: SETTING_MUTE_MUSIC}
+
+var (
+    pauseSelection int8 = PAUSE_RESUME
+    resumePending bool = false // Aguarda soltar os botões antes de voltar
+
+    // Entrada ao vivo do quadro anterior, para detectar toques
+    pausePrevGamepads [MAX_PLAYERS]uint8
+    pausePrevMouse uint8
+)
+
+// Algum jogador apertou a combinação de pausa neste quadro?
+func pauseRequested() bool {
+    requested := false
+    for i := 0; i < MAX_PLAYERS; i++ {
+        gamepad := input.Gamepad(i)
+        pressed := gamepad & ^pausePrevGamepads[i]
+        // No ar, Baixo + Botão 2 é tiro para baixo
+        airborne := players[i].flags&0x03 == 0x02
+        if players[i].joined && !airborne && gamepad&BUTTON_DOWN != 0 && pressed&BUTTON_2 != 0 {
+            requested = true
+        }
+        pausePrevGamepads[i] = gamepad
+    }
+    if mouseMiddlePressed() {
+        requested = true
+    }
+    return requested
+}
+
+// O mouse não é sincronizado no netplay, então lá ele não pausa
+func mouseMiddlePressed() bool {
+    mouse := input.MouseButtons()
+    pressed := mouse & ^pausePrevMouse
+    pausePrevMouse = mouse
+    return pressed&MOUSE_MIDDLE != 0 && input.Netplay()&NETPLAY_ACTIVE == 0
+}
+
+// Ignora botões que já estavam apertados quando a partida começou
+func syncPauseInput() {
+    for i := 0; i < MAX_PLAYERS; i++ {
+        pausePrevGamepads[i] = input.Gamepad(i)
+    }
+    pausePrevMouse = input.MouseButtons()
+}
+
+func enterPause() {
+    gameState = STATE_PAUSED
+    pauseSelection = PAUSE_RESUME
+    resumePending = false
+}
+
+func updatePause() {
+    // Espera os botões serem soltos para não pular/atirar ao voltar
+    if resumePending {
+        held := uint8(0)
+        for i := 0; i < MAX_PLAYERS; i++ {
+            held |= input.Gamepad(i)
+            pausePrevGamepads[i] = input.Gamepad(i)
+        }
+        if held&(BUTTON_1|BUTTON_2) == 0 {
+            gameState = STATE_PLAYING
+        }
+        return
+    }
+
+    // Navegação com o direcional; qualquer jogador controla o menu
+    pressed := uint8(0)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        gamepad := input.Gamepad(i)
+        if players[i].joined {
+            pressed |= gamepad & ^pausePrevGamepads[i]
+        }
+        pausePrevGamepads[i] = gamepad
+    }
+
+    if mouseMiddlePressed() {
+        resumePending = true
+        return
+    }
+    if pressed&BUTTON_UP != 0 {
+        pauseSelection = (pauseSelection + PAUSE_OPTIONS - 1) % PAUSE_OPTIONS
+    }
+    if pressed&BUTTON_DOWN != 0 {
+        pauseSelection = (pauseSelection + 1) % PAUSE_OPTIONS
+    }
+    if pressed&(BUTTON_1|BUTTON_2) == 0 {
+        return
+    }
+
+    switch pauseSelection {
+    case PAUSE_RESUME:
+        resumePending = true
+    case PAUSE_RESTART:
+        abortReplay()
+        gameState = STATE_PLAYING
+        if challengeRun {
+            startChallenge(runCode)
+        } else {
+            startGame()
+        }
+    case PAUSE_SFX, PAUSE_MUSIC:
+        settings ^= pauseSettings[pauseSelection]
+        writeSave()
+    case PAUSE_QUIT:
+        abortReplay()
+        gameState = STATE_MENU
+        resetGame()
+    }
+}
+
+// Escurece o jogo congelado e desenha as opções por cima
+func drawPause() {
+    setColors(0x01)
+    for y := int32(0); y < SCREEN_HEIGHT; y += 2 {
+        rect(0, y, SCREEN_WIDTH, 1)
+    }
+
+    setColors(0x41)
+    rect(36, 48, 88, 76)
+    setColors(0x04)
+    drawTextCentered("PAUSED", SCREEN_WIDTH/2, 54)
+
+    for i := int8(0); i < PAUSE_OPTIONS; i++ {
+        y := 68 + int32(i)*10
+        setColors(0x03)
+        if i == pauseSelection {
+            setColors(0x04)
+            drawSimpleChar('>', 42, y)
+        }
+        drawSimpleText(pauseLabels[i], 50, y)
+        if bit := pauseSettings[i]; bit != 0 {
+            if settings&bit != 0 {
+                drawSimpleText("OFF", 98, y)
+            } else {
+                drawSimpleText("ON", 98, y)
+            }
+        }
+    }
+}
@@ -0,0 +1,74 @@
+package main
+
+// Abstração da plataforma. Toda a lógica do jogo conversa com o hardware
+// apenas através destas interfaces: no WASM-4 elas apontam para a memória
+// mapeada e para as funções importadas (platform_wasm4.go); no host elas são
+// implementadas em memória (platform_host.go), o que permite rodar update()
+// quadro a quadro com o Go padrão.
+
+// Fonte de entrada (gamepads e mouse)
+type InputSource interface {
+    Gamepad(index int) uint8
+    MouseButtons() uint8
+    MousePosition() (int16, int16) // Em pixels da tela; pode sair dela
+    Netplay() uint8
+}
+
+// Destino do desenho
+type DrawSink interface {
+    SetPalette(colors [4]uint32)
+    SetColors(colors uint16)
+    Rect(x, y, width, height int32)
+    Blit(sprite []uint8, x, y, width, height int32, flags uint32)
+    BlitSub(sprite []uint8, x, y, width, height, srcX, srcY, stride int32, flags uint32)
+}
+
+// Flags do blit (mesmos valores do WASM-4)
+const (
+    BLIT_1BPP = 0
+    BLIT_2BPP = 1
+    BLIT_FLIP_X = 2
+    BLIT_FLIP_Y = 4
+    BLIT_ROTATE = 8
+)
+
+// Destino do som
+type SoundSink interface {
+    Tone(frequency, duration, volume, flags uint32)
+}
+
+// Armazenamento persistente; ambos retornam a quantidade de bytes transferida
+type Storage interface {
+    Read(buf []uint8) uint32
+    Write(buf []uint8) uint32
+}
+
+var (
+    input   InputSource
+    screen  DrawSink
+    sound   SoundSink
+    storage Storage
+)
+
+func usePlatform(in InputSource, draw DrawSink, snd SoundSink, disk Storage) {
+    input = in
+    screen = draw
+    sound = snd
+    storage = disk
+}
+
+func setColors(colors uint16) {
+    screen.SetColors(colors)
+}
+
+func rect(x, y, width, height int32) {
+    screen.Rect(x, y, width, height)
+}
+
+func blit(sprite []uint8, x, y, width, height int32, flags uint32) {
+    screen.Blit(sprite, x, y, width, height, flags)
+}
+
+func blitSub(sprite []uint8, x, y, width, height, srcX, srcY, stride int32, flags uint32) {
+    screen.BlitSub(sprite, x, y, width, height, srcX, srcY, stride, flags)
+}
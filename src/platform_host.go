@@ -0,0 +1,269 @@
+//go:build !tinygo.wasm
+
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+)
+
+// Backend em memória para rodar o jogo sem o WASM-4 (testes e simulação)
+type memoryPlatform struct {
+    gamepads     [4]uint8
+    mouseButtons uint8
+    mouseX       int16
+    mouseY       int16
+    netplay      uint8
+
+    palette     [4]uint32
+    drawColors  uint16
+    framebuffer [SCREEN_WIDTH * SCREEN_HEIGHT]uint8 // Índice da paleta (0-3)
+
+    tones []toneEvent // Só os do último quadro
+
+    disk     [1024]uint8
+    diskSize uint32
+}
+
+type toneEvent struct {
+    frame                              int32
+    frequency, duration, volume, flags uint32
+}
+
+var host = &memoryPlatform{}
+
+func init() {
+    usePlatform(host, host, host, host)
+}
+
+func (p *memoryPlatform) Gamepad(index int) uint8 {
+    return p.gamepads[index]
+}
+
+func (p *memoryPlatform) MouseButtons() uint8 {
+    return p.mouseButtons
+}
+
+func (p *memoryPlatform) MousePosition() (int16, int16) {
+    return p.mouseX, p.mouseY
+}
+
+func (p *memoryPlatform) Netplay() uint8 {
+    return p.netplay
+}
+
+func (p *memoryPlatform) SetPalette(colors [4]uint32) {
+    p.palette = colors
+}
+
+func (p *memoryPlatform) SetColors(colors uint16) {
+    p.drawColors = colors
+}
+
+// Mesma regra do WASM-4: cor 1 preenche, cor 2 contorna, 0 é transparente
+func (p *memoryPlatform) Rect(x, y, width, height int32) {
+    fill := uint8(p.drawColors & 0xf)
+    outline := uint8((p.drawColors >> 4) & 0xf)
+
+    for row := y; row < y+height; row++ {
+        for col := x; col < x+width; col++ {
+            edge := row == y || row == y+height-1 || col == x || col == x+width-1
+            if edge && outline != 0 {
+                p.setPixel(col, row, outline)
+            } else if fill != 0 {
+                p.setPixel(col, row, fill)
+            }
+        }
+    }
+}
+
+func (p *memoryPlatform) Blit(sprite []uint8, x, y, width, height int32, flags uint32) {
+    p.BlitSub(sprite, x, y, width, height, 0, 0, width, flags)
+}
+
+// Mesma amostragem do WASM-4: o valor de cada pixel escolhe um nibble de
+// drawColors (0 = transparente); BLIT_ROTATE gira 90° no sentido anti-horário
+func (p *memoryPlatform) BlitSub(sprite []uint8, x, y, width, height, srcX, srcY, stride int32, flags uint32) {
+    flipX := flags&BLIT_FLIP_X != 0
+    flipY := flags&BLIT_FLIP_Y != 0
+    rotate := flags&BLIT_ROTATE != 0
+    if rotate {
+        flipX = !flipX
+    }
+
+    for row := int32(0); row < height; row++ {
+        for col := int32(0); col < width; col++ {
+            sx, sy := srcX+col, srcY+row
+            if flipX {
+                sx = srcX + width - col - 1
+            }
+            if flipY {
+                sy = srcY + height - row - 1
+            }
+
+            bit := sy*stride + sx
+            var index uint16
+            if flags&BLIT_2BPP != 0 {
+                index = uint16(sprite[bit>>2]>>(6-uint(bit&3)*2)) & 0x3
+            } else {
+                index = uint16(sprite[bit>>3]>>(7-uint(bit&7))) & 0x1
+            }
+            color := uint8(p.drawColors>>(index*4)) & 0xf
+            if color == 0 {
+                continue
+            }
+            if rotate {
+                p.setPixel(x+row, y+col, color)
+            } else {
+                p.setPixel(x+col, y+row, color)
+            }
+        }
+    }
+}
+
+func (p *memoryPlatform) setPixel(x, y int32, color uint8) {
+    if x < 0 || x >= SCREEN_WIDTH || y < 0 || y >= SCREEN_HEIGHT {
+        return
+    }
+    p.framebuffer[y*SCREEN_WIDTH+x] = (color - 1) & 0x3
+}
+
+func (p *memoryPlatform) Tone(frequency, duration, volume, flags uint32) {
+    p.tones = append(p.tones, toneEvent{gameFrame, frequency, duration, volume, flags})
+}
+
+func (p *memoryPlatform) Read(buf []uint8) uint32 {
+    return uint32(copy(buf, p.disk[:p.diskSize]))
+}
+
+func (p *memoryPlatform) Write(buf []uint8) uint32 {
+    n := uint32(copy(p.disk[:], buf))
+    p.diskSize = n
+    return n
+}
+
+// Avança um quadro como o runtime do WASM-4 faria
+func (p *memoryPlatform) step() {
+    for i := range p.framebuffer {
+        p.framebuffer[i] = 0
+    }
+    p.tones = p.tones[:0]
+    update()
+}
+
+// Framebuffer em texto, um caractere por pixel
+func (p *memoryPlatform) dump() string {
+    const shades = " .+#"
+    out := make([]byte, 0, (SCREEN_WIDTH+1)*SCREEN_HEIGHT)
+    for y := 0; y < SCREEN_HEIGHT; y++ {
+        for x := 0; x < SCREEN_WIDTH; x++ {
+            out = append(out, shades[p.framebuffer[y*SCREEN_WIDTH+x]])
+        }
+        out = append(out, '\n')
+    }
+    return string(out)
+}
+
+// Simulação sem janela: roda o loop do jogo no host com um piloto simples
+func main() {
+    frames := flag.Int("frames", 3600, "quadros a simular")
+    offset := flag.Int("offset", 0, "quadros ociosos no menu antes de iniciar (muda a seed)")
+    autoplay := flag.Bool("autoplay", true, "pula e atira periodicamente")
+    dump := flag.Bool("dump", false, "imprime o último quadro em texto")
+    record := flag.String("record", "", "grava o replay da partida neste arquivo")
+    replay := flag.String("replay", "", "reproduz o replay deste arquivo")
+    playerCount := flag.Int("players", 1, "jogadores no co-op (1-4)")
+    fuzz := flag.Int("fuzz", 0, "valida o gerador de fases com tantas seeds e sai")
+    codeText := flag.String("code", "", "joga o desafio deste código em vez de uma partida normal")
+    flag.Parse()
+
+    start()
+    if *fuzz > 0 {
+        os.Exit(runFuzz(*fuzz))
+    }
+    if *replay != "" {
+        os.Exit(runReplay(*replay))
+    }
+    for i := 0; i < *offset; i++ {
+        host.step()
+    }
+    if *codeText != "" {
+        code, ok := parseCode(*codeText)
+        if !ok {
+            fmt.Fprintln(os.Stderr, "código inválido:", *codeText)
+            os.Exit(1)
+        }
+        gameState = STATE_PLAYING
+        startChallenge(code)
+    }
+
+    deathFrame := int32(-1)
+    for i := 0; i < *frames; i++ {
+        for p := 0; p < *playerCount && p < MAX_PLAYERS; p++ {
+            pad := uint8(0)
+            if gameState == STATE_MENU {
+                pad = BUTTON_1 // Entra na partida (o jogador 1 inicia)
+            } else if *autoplay && gameState == STATE_PLAYING {
+                // Cada jogador com um ritmo diferente; segura o pulo
+                // alguns quadros para não sair só um pulinho
+                if (gameFrame+int32(p)*7)%40 < 6 {
+                    pad |= BUTTON_1
+                }
+                if (gameFrame+int32(p)*5)%12 == 0 {
+                    pad |= BUTTON_2
+                }
+            }
+            host.gamepads[p] = pad
+        }
+
+        host.step()
+
+        if gameState == STATE_GAME_OVER {
+            deathFrame = gameFrame
+            break
+        }
+    }
+
+    if *dump {
+        fmt.Print(host.dump())
+    }
+    fmt.Fprintf(os.Stdout, "score=%d high=%d frame=%d death=%d\n", score, highScore, gameFrame, deathFrame)
+
+    if *record != "" && deathFrame >= 0 {
+        if replayTruncated {
+            fmt.Fprintln(os.Stderr, "partida longa demais para o replay; gravado só o começo (replay parcial)")
+        }
+        if err := os.WriteFile(*record, replayBytes(), 0o644); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+    }
+}
+
+// Reproduz um replay do disco e confere o resultado com o gravado
+func runReplay(path string) int {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return 1
+    }
+    if !loadReplay(data) {
+        fmt.Fprintln(os.Stderr, "replay inválido:", path)
+        return 1
+    }
+
+    gameState = STATE_PLAYING
+    startPlayback()
+    for gameState == STATE_PLAYING {
+        host.step()
+    }
+
+    wantFrame, wantScore := replayResult()
+    fmt.Printf("score=%d death=%d expected_score=%d expected_death=%d partial=%v match=%v\n",
+        score, gameFrame, wantScore, wantFrame, replayPartial(), playbackMatched)
+    if !playbackMatched {
+        return 2
+    }
+    return 0
+}
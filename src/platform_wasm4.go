@@ -0,0 +1,79 @@
+//go:build tinygo.wasm
+
+package main
+
+import (
+    "unsafe"
+
+    "jump-shoot-wasm4/w4"
+)
+
+// Backend do WASM-4: repassa tudo para a memória mapeada e as funções do host
+type wasm4Platform struct{}
+
+func init() {
+    var p wasm4Platform
+    usePlatform(p, p, p, p)
+}
+
+func (wasm4Platform) Gamepad(index int) uint8 {
+    switch index {
+    case 1:
+        return *w4.GAMEPAD2
+    case 2:
+        return *w4.GAMEPAD3
+    case 3:
+        return *w4.GAMEPAD4
+    }
+    return *w4.GAMEPAD1
+}
+
+func (wasm4Platform) MouseButtons() uint8 {
+    return *w4.MOUSE_BUTTONS
+}
+
+func (wasm4Platform) MousePosition() (int16, int16) {
+    return *w4.MOUSE_X, *w4.MOUSE_Y
+}
+
+func (wasm4Platform) Netplay() uint8 {
+    return *w4.NETPLAY
+}
+
+func (wasm4Platform) SetPalette(colors [4]uint32) {
+    *w4.PALETTE = colors
+}
+
+func (wasm4Platform) SetColors(colors uint16) {
+    *w4.DRAW_COLORS = colors
+}
+
+func (wasm4Platform) Rect(x, y, width, height int32) {
+    w4.Rect(int(x), int(y), uint(width), uint(height))
+}
+
+func (wasm4Platform) Blit(sprite []uint8, x, y, width, height int32, flags uint32) {
+    w4.Blit(&sprite[0], int(x), int(y), uint(width), uint(height), uint(flags))
+}
+
+func (wasm4Platform) BlitSub(sprite []uint8, x, y, width, height, srcX, srcY, stride int32, flags uint32) {
+    w4.BlitSub(&sprite[0], int(x), int(y), uint(width), uint(height), uint(srcX), uint(srcY), int(stride), uint(flags))
+}
+
+func (wasm4Platform) Tone(frequency, duration, volume, flags uint32) {
+    w4.Tone(uint(frequency), uint(duration), uint(volume), uint(flags))
+}
+
+func (wasm4Platform) Read(buf []uint8) uint32 {
+    if len(buf) == 0 {
+        return 0
+    }
+    return uint32(w4.DiskR(unsafe.Pointer(&buf[0]), uint(len(buf))))
+}
+
+func (wasm4Platform) Write(buf []uint8) uint32 {
+    if len(buf) == 0 {
+        return 0
+    }
+    return uint32(w4.DiskW(unsafe.Pointer(&buf[0]), uint(len(buf))))
+}
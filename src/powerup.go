@@ -0,0 +1,114 @@
+package main
+
+// Power-ups. Alguns trechos da fase (level.go) deixam uma cápsula
+// (KIND_PICKUP) no caminho; o jogador que encostar nela ganha o efeito por
+// um tempo. Pegar o mesmo power-up de novo só renova o tempo.
+const (
+    POWERUP_SPREAD = 0     // Três tiros em leque por bala
+    POWERUP_RAPID = 1      // Recarga na metade do tempo
+    POWERUP_MAGAZINE = 2   // Pente com MAGAZINE_BONUS balas a mais
+    POWERUP_SHIELD = 3     // Absorve um acerto
+    POWERUP_MULTIPLIER = 4 // Pontos em dobro
+    POWERUP_COUNT = 5
+
+    POWERUP_FRAMES = 600 // 10 segundos
+    POWERUP_SHIELD_FRAMES = 900
+    POWERUP_WARNING = 120 // Indicador pisca nos últimos 2 segundos
+    MAGAZINE_BONUS = 4
+    MAX_PICKUPS = 2
+)
+
+// Letra da cápsula e do HUD
+var powerupLetters = [POWERUP_COUNT]byte{
+    POWERUP_SPREAD: 'S',
+    POWERUP_RAPID: 'R',
+    POWERUP_MAGAZINE: 'M',
+    POWERUP_SHIELD: 'E',
+    POWERUP_MULTIPLIER: 'X',
+}
+
+var powerupDurations = [POWERUP_COUNT]uint16{
+    POWERUP_SPREAD: POWERUP_FRAMES,
+    POWERUP_RAPID: POWERUP_FRAMES,
+    POWERUP_MAGAZINE: POWERUP_FRAMES,
+    POWERUP_SHIELD: POWERUP_SHIELD_FRAMES,
+    POWERUP_MULTIPLIER: POWERUP_FRAMES,
+}
+
+// Cápsula com o power-up dado
+func spawnPickup(x, y int32, powerup int8) {
+    if slot := spawnEntity(KIND_PICKUP, x, y, 0, 0); slot >= 0 {
+        entities[slot].variant = powerup
+    }
+}
+
+func hasPowerup(p int, powerup int) bool {
+    return players[p].powerups[powerup] > 0
+}
+
+func collectPickup(p int, e *entity) {
+    pl := &players[p]
+    powerup := int(e.variant)
+    pl.powerups[powerup] = powerupDurations[powerup]
+    if powerup == POWERUP_MAGAZINE && !pl.isReloading {
+        pl.ammo = maxAmmo(p)
+    }
+    e.active = false
+    playSfx(SFX_PICKUP)
+}
+
+// Conta o tempo de cada efeito; o pente extra sobra só até o fim dele
+func updatePowerups(p int) {
+    pl := &players[p]
+    for i := 0; i < POWERUP_COUNT; i++ {
+        if pl.powerups[i] == 0 {
+            continue
+        }
+        pl.powerups[i]--
+        if i == POWERUP_MAGAZINE && pl.powerups[i] == 0 && pl.ammo > MAX_AMMO {
+            pl.ammo = MAX_AMMO
+        }
+    }
+}
+
+func maxAmmo(p int) int32 {
+    if hasPowerup(p, POWERUP_MAGAZINE) {
+        return MAX_AMMO + MAGAZINE_BONUS
+    }
+    return MAX_AMMO
+}
+
+func reloadTime(p int) int32 {
+    if hasPowerup(p, POWERUP_RAPID) {
+        return RELOAD_TIME / 2
+    }
+    return RELOAD_TIME
+}
+
+// Letras dos power-ups ativos, a partir de x
+func drawPowerups(p int, x, y int32) {
+    for i := 0; i < POWERUP_COUNT; i++ {
+        timer := players[p].powerups[i]
+        if timer == 0 {
+            continue
+        }
+        setColors(0x04)
+        if timer < POWERUP_WARNING && (timer/8)%2 == 1 {
+            setColors(0x02)
+        }
+        drawSimpleChar(powerupLetters[i], x, y)
+        x += charWidth(powerupLetters[i]) + 3
+    }
+}
+
+// Cápsula com a letra do power-up, flutuando
+func drawPickup(e *entity, screenX int32) {
+    y := e.y
+    if (e.age/20)%2 == 1 {
+        y--
+    }
+    drawSprite(SPRITE_PICKUP, 0, screenX, y, 0x23, 0)
+    letter := powerupLetters[e.variant]
+    setColors(0x04)
+    drawSimpleChar(letter, screenX+(SPRITE_PICKUP_WIDTH-charWidth(letter))/2, y+1)
+}
@@ -0,0 +1,74 @@
+package main
+
+// Números pseudo-aleatórios. Cada parte do jogo que sorteia tem o próprio
+// stream PCG32 (PCG-XSH-RR 64/32, de O'Neill), todos semeados da seed da
+// partida em seedStreams(): assim um sorteio a mais na IA ou num efeito não
+// muda a fase, e a fase depende só da seed (o desafio de seed.go conta com
+// isso). Os streams usam a mesma seed com incrementos diferentes, o que no
+// PCG dá sequências independentes.
+const (
+    PCG_MULTIPLIER = 6364136223846793005
+
+    // Streams
+    STREAM_LEVEL = 1    // Trechos da fase e o sorteio de cada coluna
+    STREAM_AI = 2       // Decisões dos inimigos e do chefe
+    STREAM_COSMETIC = 3 // Efeitos que não mudam a partida
+)
+
+type pcg32 struct {
+    state uint64
+    inc uint64 // Sempre ímpar; escolhe o stream
+}
+
+var levelRand, aiRand, cosmeticRand pcg32
+
+// Seed inicial de cada stream; chamado por beginRun()
+func seedStreams(seed uint32) {
+    levelRand.seed(uint64(seed), STREAM_LEVEL)
+    aiRand.seed(uint64(seed), STREAM_AI)
+    cosmeticRand.seed(uint64(seed), STREAM_COSMETIC)
+}
+
+// Inicialização de referência do PCG (pcg32_srandom_r)
+func (r *pcg32) seed(seed, stream uint64) {
+    r.state = 0
+    r.inc = stream<<1 | 1
+    r.next()
+    r.state += seed
+    r.next()
+}
+
+func (r *pcg32) next() uint32 {
+    old := r.state
+    r.state = old*PCG_MULTIPLIER + r.inc
+    xorshifted := uint32(((old >> 18) ^ old) >> 27)
+    rot := uint32(old >> 59)
+    return xorshifted>>rot | xorshifted<<((-rot)&31)
+}
+
+// Número em [0, bound) sem viés: descarta o pedaço do começo da faixa de
+// 32 bits que não cabe um número inteiro de vezes em bound
+func (r *pcg32) bounded(bound uint32) uint32 {
+    threshold := -bound % bound
+    for {
+        if v := r.next(); v >= threshold {
+            return v % bound
+        }
+    }
+}
+
+// Número em [0, max); max precisa ser positivo
+func (r *pcg32) intn(max int32) int32 {
+    return int32(r.bounded(uint32(max)))
+}
+
+// Espalha os bits de um valor qualquer (finalizador do MurmurHash3); dá uma
+// seed a partir do relógio ou de um código
+func mix32(h uint32) uint32 {
+    h ^= h >> 16
+    h *= 0x85EBCA6B
+    h ^= h >> 13
+    h *= 0xC2B2AE35
+    h ^= h >> 16
+    return h
+}
@@ -0,0 +1,291 @@
+package main
+
+// Gravação e reprodução determinística de partidas.
+//
+// Stream do replay (little-endian):
+//
+//    0  seed              4 bytes (do relógio em startGame, ou a do código)
+//    4  jogadores         1 byte  (bit i = jogador i participa; bit 7 =
+//                                  REPLAY_PARTIAL)
+//    5  prevGamepad       4 bytes (estado anterior de cada jogador, usado
+//                                  na detecção de toque)
+//    9  prevMouseButtons  1 byte
+//   10  deathFrame        4 bytes (gameFrame no fim de jogo, ou no último
+//                                  quadro gravado de um replay parcial)
+//   14  finalScore        4 bytes (idem)
+//   18  código            4 bytes (do desafio, ou CODE_NONE; ver seed.go)
+//   22  runs              repetições (1-255), botões do mouse, posição do
+//                         mouse (x, y) e um gamepad por jogador
+//                         participante, nessa ordem
+//
+// Cada run cobre quadros consecutivos de updateGame() com a mesma entrada.
+// A posição do mouse só é gravada com o botão direito apertado (é quando ela
+// muda a partida); no resto do tempo fica 0, para não quebrar os runs.
+//
+// Uma partida normal gasta pouco mais de um byte por quadro, então o buffer
+// cabe uns quatro minutos. Se ele encher, a gravação para ali e o replay
+// fica parcial: a reprodução termina no último quadro gravado e confere o
+// quadro e a pontuação daquele ponto.
+const (
+    REPLAY_HEADER_SIZE = 22
+    REPLAY_MAX_SIZE = REPLAY_HEADER_SIZE + 16384
+    REPLAY_PARTIAL = 0x80 // No byte dos jogadores
+
+    // Modos do replay
+    REPLAY_OFF = 0
+    REPLAY_RECORDING = 1
+    REPLAY_PLAYING = 2
+)
+
+var (
+    replayData [REPLAY_MAX_SIZE]uint8
+    replayLen int32 = 0 // 0 = nenhuma partida gravada
+    replayRunSize int32 = 5
+    replayTruncated bool = false // O buffer encheu: o replay é parcial
+    replayMode uint8 = REPLAY_OFF
+
+    // Leitura durante a reprodução
+    playbackPos int32 = 0
+    playbackLeft uint8 = 0
+    playbackMatched bool = false
+    playbackDone bool = false
+)
+
+// Entrada usada por handleInput() no quadro atual
+var (
+    frameGamepads [MAX_PLAYERS]uint8
+    frameMouseButtons uint8
+    frameMouseX, frameMouseY uint8 // Preso à tela
+)
+
+func startRecording(seed uint32) {
+    replayMode = REPLAY_RECORDING
+    replayTruncated = false
+    putU32(replayData[:], 0, seed)
+    mask := uint8(0)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if players[i].joined {
+            mask |= 1 << uint(i)
+        }
+        replayData[5+i] = players[i].prevGamepad
+    }
+    replayData[4] = mask
+    replayData[9] = players[0].prevMouseButtons
+    putU32(replayData[:], 10, 0)
+    putU32(replayData[:], 14, 0)
+    code := uint32(CODE_NONE)
+    if challengeRun {
+        code = runCode
+    }
+    putU32(replayData[:], 18, code)
+    replayLen = REPLAY_HEADER_SIZE
+    replayRunSize = runSizeFor(mask)
+    playbackDone = false
+}
+
+func finishRecording() {
+    if replayMode != REPLAY_RECORDING {
+        return
+    }
+    replayMode = REPLAY_OFF
+    if replayTruncated {
+        return // O cabeçalho já tem o ponto em que a gravação parou
+    }
+    putU32(replayData[:], 10, uint32(gameFrame))
+    putU32(replayData[:], 14, uint32(score))
+}
+
+// Bytes por run: repetições, mouse e os gamepads dos participantes
+func runSizeFor(mask uint8) int32 {
+    size := int32(4)
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if mask&(1<<uint(i)) != 0 {
+            size++
+        }
+    }
+    return size
+}
+
+// Partida interrompida pelo menu de pausa: uma gravação incompleta é
+// descartada, e uma reprodução simplesmente para
+func abortReplay() {
+    if replayMode == REPLAY_RECORDING {
+        replayLen = 0
+    }
+    replayMode = REPLAY_OFF
+}
+
+// Há uma partida gravada para reproduzir?
+func replayAvailable() bool {
+    return replayMode == REPLAY_OFF && replayLen > REPLAY_HEADER_SIZE
+}
+
+// O replay gravado parou antes do fim da partida?
+func replayPartial() bool {
+    return replayData[4]&REPLAY_PARTIAL != 0
+}
+
+// Um replay parcial acaba quando os runs acabam
+func playbackEnded() bool {
+    return replayMode == REPLAY_PLAYING && replayPartial() && playbackLeft == 0 &&
+        playbackPos+replayRunSize > replayLen
+}
+
+func startPlayback() {
+    replayMode = REPLAY_PLAYING
+    mask := replayData[4]
+    for i := 0; i < MAX_PLAYERS; i++ {
+        players[i].joined = mask&(1<<uint(i)) != 0
+        players[i].prevGamepad = replayData[5+i]
+        players[i].prevMouseButtons = 0
+    }
+    players[0].prevMouseButtons = replayData[9]
+    replayRunSize = runSizeFor(mask)
+    playbackPos = REPLAY_HEADER_SIZE
+    playbackLeft = 0
+    playbackDone = false
+    runCode = getU32(replayData[:], 18)
+    challengeRun = runCode != CODE_NONE
+    beginRun(getU32(replayData[:], 0))
+}
+
+// Confere se a reprodução terminou igual à gravação
+func finishPlayback() {
+    playbackMatched = uint32(gameFrame) == getU32(replayData[:], 10) &&
+        uint32(score) == getU32(replayData[:], 14)
+    playbackDone = true
+    replayMode = REPLAY_OFF
+}
+
+// Preenche a entrada do quadro: lida do replay durante a reprodução,
+// e gravada no stream durante uma partida normal
+func readFrameInput() {
+    if replayMode == REPLAY_PLAYING {
+        nextPlaybackInput()
+        return
+    }
+
+    for i := 0; i < MAX_PLAYERS; i++ {
+        frameGamepads[i] = input.Gamepad(i)
+    }
+    // O mouse não é sincronizado no netplay
+    frameMouseButtons = 0
+    frameMouseX, frameMouseY = 0, 0
+    if input.Netplay()&NETPLAY_ACTIVE == 0 {
+        frameMouseButtons = input.MouseButtons()
+        if frameMouseButtons&MOUSE_RIGHT != 0 {
+            frameMouseX, frameMouseY = mouseScreenPosition()
+        }
+    }
+
+    if replayMode == REPLAY_RECORDING {
+        recordInput()
+    }
+}
+
+// Compara a entrada do quadro com um run gravado
+func sameInput(run int32) bool {
+    if replayData[run+1] != frameMouseButtons || replayData[run+2] != frameMouseX ||
+       replayData[run+3] != frameMouseY {
+        return false
+    }
+    pos := run + 4
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if players[i].joined {
+            if replayData[pos] != frameGamepads[i] {
+                return false
+            }
+            pos++
+        }
+    }
+    return true
+}
+
+func recordInput() {
+    if replayTruncated {
+        return
+    }
+    // Estende o último run se a entrada não mudou
+    last := replayLen - replayRunSize
+    if last >= REPLAY_HEADER_SIZE && replayData[last] < 255 && sameInput(last) {
+        replayData[last]++
+        return
+    }
+
+    // Buffer cheio: o replay fica parcial, até o quadro anterior a este
+    if replayLen+replayRunSize > REPLAY_MAX_SIZE {
+        replayTruncated = true
+        replayData[4] |= REPLAY_PARTIAL
+        putU32(replayData[:], 10, uint32(gameFrame-1))
+        putU32(replayData[:], 14, uint32(score))
+        return
+    }
+    replayData[replayLen] = 1
+    replayData[replayLen+1] = frameMouseButtons
+    replayData[replayLen+2] = frameMouseX
+    replayData[replayLen+3] = frameMouseY
+    pos := replayLen + 4
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if players[i].joined {
+            replayData[pos] = frameGamepads[i]
+            pos++
+        }
+    }
+    replayLen += replayRunSize
+}
+
+func nextPlaybackInput() {
+    for i := 0; i < MAX_PLAYERS; i++ {
+        frameGamepads[i] = 0
+    }
+    frameMouseButtons = 0
+    frameMouseX, frameMouseY = 0, 0
+
+    if playbackLeft == 0 {
+        if playbackPos+replayRunSize > replayLen {
+            return // Fim do stream
+        }
+        playbackLeft = replayData[playbackPos]
+        playbackPos += replayRunSize
+    }
+    playbackLeft--
+
+    run := playbackPos - replayRunSize
+    frameMouseButtons = replayData[run+1]
+    frameMouseX, frameMouseY = replayData[run+2], replayData[run+3]
+    pos := run + 4
+    for i := 0; i < MAX_PLAYERS; i++ {
+        if players[i].joined {
+            frameGamepads[i] = replayData[pos]
+            pos++
+        }
+    }
+}
+
+// Stream gravado, para exportar
+func replayBytes() []uint8 {
+    return replayData[:replayLen]
+}
+
+// Carrega um stream exportado; falha se estiver malformado
+func loadReplay(data []uint8) bool {
+    if len(data) < REPLAY_HEADER_SIZE || len(data) > REPLAY_MAX_SIZE || data[4]&1 == 0 ||
+       int32(len(data)-REPLAY_HEADER_SIZE)%runSizeFor(data[4]) != 0 {
+        return false
+    }
+    // O gravador nunca escreve um run com zero repetições
+    for pos := REPLAY_HEADER_SIZE; pos < len(data); pos += int(runSizeFor(data[4])) {
+        if data[pos] == 0 {
+            return false
+        }
+    }
+    replayLen = int32(copy(replayData[:], data))
+    replayTruncated = false
+    replayMode = REPLAY_OFF
+    return true
+}
+
+// Quadro e pontuação registrados no cabeçalho
+func replayResult() (int32, int32) {
+    return int32(getU32(replayData[:], 10)), int32(getU32(replayData[:], 14))
+}
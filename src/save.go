@@ -0,0 +1,207 @@
+package main
+
+// Registro salvo no disco do WASM-4 (little-endian):
+//
+//    0  magic "JNS"       3 bytes
+//    3  versão            1 byte
+//    4  highScore         4 bytes
+//    8  settings          1 byte
+//    9  gamesPlayed       4 bytes
+//   13  totalKills        4 bytes
+//   17  totalScore        4 bytes
+//   21  desafios          8 × (código 4 bytes, melhor pontuação 4 bytes),
+//                         o jogado mais recentemente primeiro (versão 2)
+//   85  checksum          2 bytes (Fletcher-16 de todos os bytes anteriores)
+//
+// Versões novas só acrescentam campos antes do checksum, então um registro
+// antigo continua legível: os campos que ele não tem ficam com o padrão.
+const (
+    SAVE_VERSION = 2
+    SAVE_HEADER_SIZE = 4
+    SAVE_CHECKSUM_SIZE = 2
+    SAVE_V1_SIZE = 23
+    SAVE_V2_SIZE = 87
+    SAVE_CHALLENGES = 8 // Códigos de desafio lembrados
+
+    // Bits de settings
+    SETTING_MUTE_SFX   = 1
+    SETTING_MUTE_MUSIC = 2
+)
+
+var saveMagic = [3]uint8{'J', 'N', 'S'}
+
+// Tamanho do registro em cada versão (índice = versão)
+var saveRecordSizes = [SAVE_VERSION + 1]uint32{0, SAVE_V1_SIZE, SAVE_V2_SIZE}
+
+// Perfil do jogador persistido entre sessões
+var (
+    settings    uint8  = 0
+    gamesPlayed uint32 = 0
+    totalKills  uint32 = 0
+    totalScore  uint32 = 0
+    runKills    uint32 = 0 // Inimigos abatidos na partida atual
+
+    // Melhor pontuação por código de desafio (seed.go); code = CODE_NONE
+    // numa entrada vazia
+    challengeBests [SAVE_CHALLENGES]struct {
+        code uint32
+        best int32
+    }
+)
+
+var saveBuffer [SAVE_V2_SIZE]uint8
+
+// Carrega o perfil do disco (até 1024 bytes). Disco vazio, corrompido ou de versão
+// desconhecida mantém os valores padrão.
+func loadSave() {
+    resetProfile()
+
+    n := storage.Read(saveBuffer[:])
+    if n < SAVE_HEADER_SIZE {
+        return // Disco vazio
+    }
+    if saveBuffer[0] != saveMagic[0] || saveBuffer[1] != saveMagic[1] || saveBuffer[2] != saveMagic[2] {
+        return
+    }
+
+    version := saveBuffer[3]
+    if version == 0 || version > SAVE_VERSION {
+        return
+    }
+    size := saveRecordSizes[version]
+    if n < size {
+        return // Registro truncado
+    }
+    if checksum(saveBuffer[:size-SAVE_CHECKSUM_SIZE]) != getU16(saveBuffer[:], size-SAVE_CHECKSUM_SIZE) {
+        return
+    }
+
+    // Campos da versão 1
+    highScore = int32(getU32(saveBuffer[:], 4))
+    settings = saveBuffer[8]
+    gamesPlayed = getU32(saveBuffer[:], 9)
+    totalKills = getU32(saveBuffer[:], 13)
+    totalScore = getU32(saveBuffer[:], 17)
+    if version < 2 {
+        return
+    }
+
+    // Campos da versão 2
+    for i := range challengeBests {
+        off := uint32(21 + i*8)
+        challengeBests[i].code = getU32(saveBuffer[:], off)
+        challengeBests[i].best = int32(getU32(saveBuffer[:], off+4))
+    }
+}
+
+// Grava o perfil sempre no formato da versão atual
+func writeSave() {
+    saveBuffer[0] = saveMagic[0]
+    saveBuffer[1] = saveMagic[1]
+    saveBuffer[2] = saveMagic[2]
+    saveBuffer[3] = SAVE_VERSION
+    putU32(saveBuffer[:], 4, uint32(highScore))
+    saveBuffer[8] = settings
+    putU32(saveBuffer[:], 9, gamesPlayed)
+    putU32(saveBuffer[:], 13, totalKills)
+    putU32(saveBuffer[:], 17, totalScore)
+    for i := range challengeBests {
+        off := uint32(21 + i*8)
+        putU32(saveBuffer[:], off, challengeBests[i].code)
+        putU32(saveBuffer[:], off+4, uint32(challengeBests[i].best))
+    }
+    putU16(saveBuffer[:], SAVE_V2_SIZE-SAVE_CHECKSUM_SIZE, checksum(saveBuffer[:SAVE_V2_SIZE-SAVE_CHECKSUM_SIZE]))
+
+    storage.Write(saveBuffer[:SAVE_V2_SIZE])
+}
+
+func resetProfile() {
+    highScore = 0
+    settings = 0
+    gamesPlayed = 0
+    totalKills = 0
+    totalScore = 0
+    for i := range challengeBests {
+        challengeBests[i].code = CODE_NONE
+        challengeBests[i].best = 0
+    }
+}
+
+// Soma a partida encerrada ao perfil e persiste. O desafio tem recorde
+// próprio e não mexe no normal
+func recordRun() {
+    if challengeRun {
+        recordChallenge(runCode, score)
+    } else if score > highScore {
+        highScore = score
+    }
+    gamesPlayed++
+    totalKills += runKills
+    totalScore += uint32(score)
+    writeSave()
+}
+
+// Põe o código na frente da lista (o mais antigo sai se ela estiver cheia)
+// guardando o melhor resultado dele
+func recordChallenge(code uint32, result int32) {
+    last := SAVE_CHALLENGES - 1
+    for i := range challengeBests {
+        if challengeBests[i].code == code {
+            last = i
+            if challengeBests[i].best > result {
+                result = challengeBests[i].best
+            }
+            break
+        }
+    }
+    copy(challengeBests[1:last+1], challengeBests[:last])
+    challengeBests[0].code = code
+    challengeBests[0].best = result
+}
+
+func challengeBest(code uint32) int32 {
+    for i := range challengeBests {
+        if challengeBests[i].code == code {
+            return challengeBests[i].best
+        }
+    }
+    return 0
+}
+
+// O editor começa no último desafio jogado
+func lastChallengeCode() uint32 {
+    if challengeBests[0].code == CODE_NONE {
+        return 0
+    }
+    return challengeBests[0].code
+}
+
+// Fletcher-16
+func checksum(data []uint8) uint16 {
+    var sum1, sum2 uint16
+    for i := 0; i < len(data); i++ {
+        sum1 = (sum1 + uint16(data[i])) % 255
+        sum2 = (sum2 + sum1) % 255
+    }
+    return sum2<<8 | sum1
+}
+
+func getU16(buf []uint8, off uint32) uint16 {
+    return uint16(buf[off]) | uint16(buf[off+1])<<8
+}
+
+func putU16(buf []uint8, off uint32, v uint16) {
+    buf[off] = uint8(v)
+    buf[off+1] = uint8(v >> 8)
+}
+
+func getU32(buf []uint8, off uint32) uint32 {
+    return uint32(buf[off]) | uint32(buf[off+1])<<8 | uint32(buf[off+2])<<16 | uint32(buf[off+3])<<24
+}
+
+func putU32(buf []uint8, off uint32, v uint32) {
+    buf[off] = uint8(v)
+    buf[off+1] = uint8(v >> 8)
+    buf[off+2] = uint8(v >> 16)
+    buf[off+3] = uint8(v >> 24)
+}
@@ -0,0 +1,167 @@
+package main
+
+// Desafio com código. Em vez da seed do relógio, a partida usa uma seed
+// tirada de um código curto de CODE_LENGTH letras, mostrado e editado no
+// menu: quem joga o mesmo código enfrenta a mesma fase. Para isso, no
+// desafio tudo que decide a fase segue a distância percorrida e não a
+// pontuação (runProgress): a rampa de dificuldade, o sorteio dos trechos,
+// o tipo dos inimigos e a hora dos chefes. O melhor resultado de cada
+// código fica salvo à parte do recorde normal (save.go).
+const (
+    CODE_LENGTH = 5
+    CODE_BITS = 5 // Por letra; codeAlphabet tem 32 símbolos
+    CODE_NONE = 0xFFFFFFFF // Nenhum código (partida normal)
+    CODE_PIXELS_PER_POINT = 32 // No desafio, cada 32 pixels valem um ponto de progresso
+)
+
+// Sem I, O, 0 e 1, que se confundem
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var (
+    challengeRun bool = false // A partida atual é um desafio
+    runCode uint32 = 0        // Código do desafio atual, ou o que está no editor
+
+    // Editor do código no menu
+    codeEditing bool = false
+    codeClosing bool = false // Aguarda soltar os botões depois de fechar
+    codeCursor int32 = 0
+    menuPrevGamepad uint8
+)
+
+// Espalha os bits do código para que códigos vizinhos deem fases diferentes
+func codeSeed(code uint32) uint32 {
+    return mix32(code)
+}
+
+// Símbolo na posição i (0 = o da esquerda)
+func codeSymbol(code uint32, i int32) uint32 {
+    return (code >> uint((CODE_LENGTH-1-i)*CODE_BITS)) & (1<<CODE_BITS - 1)
+}
+
+func setCodeSymbol(code uint32, i int32, symbol uint32) uint32 {
+    shift := uint((CODE_LENGTH - 1 - i) * CODE_BITS)
+    return code&^((1<<CODE_BITS-1)<<shift) | (symbol&(1<<CODE_BITS-1))<<shift
+}
+
+// Lê um código digitado; minúsculas valem
+func parseCode(text string) (uint32, bool) {
+    if len(text) != CODE_LENGTH {
+        return 0, false
+    }
+    code := uint32(0)
+    for i := 0; i < CODE_LENGTH; i++ {
+        c := text[i]
+        if c >= 'a' && c <= 'z' {
+            c -= 'a' - 'A'
+        }
+        symbol := -1
+        for j := 0; j < len(codeAlphabet); j++ {
+            if codeAlphabet[j] == c {
+                symbol = j
+            }
+        }
+        if symbol < 0 {
+            return 0, false
+        }
+        code = code<<CODE_BITS | uint32(symbol)
+    }
+    return code, true
+}
+
+func startChallenge(code uint32) {
+    runCode = code
+    challengeRun = true
+    seed := codeSeed(code)
+    startRecording(seed)
+    beginRun(seed)
+}
+
+// Quanto a dificuldade já subiu: a pontuação, ou no desafio a distância
+func runProgress() int32 {
+    if challengeRun {
+        return distanceProgress(cameraX)
+    }
+    return score
+}
+
+func distanceProgress(x int32) int32 {
+    return x / CODE_PIXELS_PER_POINT
+}
+
+// No desafio, o que a fase decide na coluna em x usa a dificuldade daquele
+// ponto, e não a do quadro em que a câmera chegou lá. leaveLevelDifficulty()
+// volta à dificuldade do quadro
+func enterLevelDifficulty(x int32) {
+    if challengeRun {
+        setDifficulty(distanceProgress(x))
+    }
+}
+
+func leaveLevelDifficulty() {
+    if challengeRun {
+        updateSpeeds()
+    }
+}
+
+// Editor no menu: cima/baixo troca a letra, esquerda/direita escolhe qual,
+// botão 1 joga e botão 2 volta
+func updateCodeEditor(pressed uint8) {
+    if pressed&BUTTON_LEFT != 0 && codeCursor > 0 {
+        codeCursor--
+    }
+    if pressed&BUTTON_RIGHT != 0 && codeCursor < CODE_LENGTH-1 {
+        codeCursor++
+    }
+    symbol := codeSymbol(runCode, codeCursor)
+    if pressed&BUTTON_UP != 0 {
+        runCode = setCodeSymbol(runCode, codeCursor, symbol+1)
+    }
+    if pressed&BUTTON_DOWN != 0 {
+        runCode = setCodeSymbol(runCode, codeCursor, symbol-1)
+    }
+
+    if pressed&BUTTON_1 != 0 {
+        codeEditing = false
+        gameState = STATE_PLAYING
+        startChallenge(runCode)
+    } else if pressed&BUTTON_2 != 0 {
+        codeEditing = false
+        codeClosing = true
+    }
+}
+
+func openCodeEditor() {
+    codeEditing = true
+    codeCursor = 0
+    runCode = lastChallengeCode()
+}
+
+func drawCodeEditor() {
+    setColors(0x04)
+    drawSimpleText("CODE:", 44, 60)
+    x := int32(78)
+    for i := int32(0); i < CODE_LENGTH; i++ {
+        setColors(0x03)
+        if i == codeCursor {
+            setColors(0x04)
+            rect(x, 68, 5, 1)
+        }
+        drawSimpleChar(codeAlphabet[codeSymbol(runCode, i)], x, 60)
+        x += 8
+    }
+    setColors(0x03)
+    drawSimpleText("BEST:", 44, 72)
+    drawNumber(challengeBest(runCode), 78, 72)
+    drawTextCentered("X:PLAY Z:BACK", SCREEN_WIDTH/2, 110)
+}
+
+// Código da partida, para a tela de fim de jogo
+func drawRunCode(y int32) {
+    x := SCREEN_WIDTH/2 - textWidth("CODE: AAAAA")/2
+    drawSimpleText("CODE:", x, y)
+    x += textWidth("CODE: ")
+    for i := int32(0); i < CODE_LENGTH; i++ {
+        drawSimpleChar(codeAlphabet[codeSymbol(runCode, i)], x, y)
+        x += 6
+    }
+}
@@ -0,0 +1,96 @@
+package main
+
+const (
+    // Canais e flags do tone
+    TONE_PULSE1 = 0
+    TONE_PULSE2 = 1
+    TONE_TRIANGLE = 2
+    TONE_NOISE = 3
+    TONE_MODE1 = 0
+    TONE_MODE2 = 4
+    TONE_MODE3 = 8
+    TONE_MODE4 = 12
+    TONE_NOTE_MODE = 64
+
+    // Efeitos sonoros
+    SFX_NONE = -1
+    SFX_JUMP = 0
+    SFX_SHOOT = 1
+    SFX_EMPTY_CLICK = 2
+    SFX_RELOAD_DONE = 3
+    SFX_ENEMY_EXPLODE = 4
+    SFX_BULLET_INTERCEPT = 5
+    SFX_PLAYER_DEATH = 6
+    SFX_ENEMY_HIT = 7
+    SFX_PLAYER_HURT = 8
+    SFX_SHIELD_BLOCK = 9
+    SFX_BOSS_SLAM = 10
+    SFX_PICKUP = 11
+    SFX_COUNT = 12
+)
+
+// Um efeito é um único tone com slide de frequência e envelope ADSR
+// (durações em quadros). Efeitos de prioridade menor não interrompem
+// um efeito mais importante que ainda toca no mesmo canal.
+type sfxDef struct {
+    freqStart, freqEnd uint16
+    attack, decay, sustain, release uint8
+    peak, volume uint8
+    channel uint8 // TONE_PULSE1..TONE_NOISE
+    mode uint8    // Duty cycle dos canais pulse
+    priority uint8
+}
+
+var sfxDefs = [SFX_COUNT]sfxDef{
+    SFX_JUMP:             {200, 480, 0, 0, 6, 4, 0, 40, TONE_PULSE1, TONE_MODE3, 1},
+    SFX_SHOOT:            {700, 200, 0, 0, 3, 4, 0, 30, TONE_NOISE, 0, 1},
+    SFX_EMPTY_CLICK:      {120, 0, 0, 0, 2, 1, 0, 25, TONE_PULSE2, TONE_MODE1, 1},
+    SFX_RELOAD_DONE:      {660, 990, 0, 0, 6, 4, 0, 35, TONE_PULSE2, TONE_MODE2, 2},
+    SFX_ENEMY_EXPLODE:    {300, 60, 0, 2, 8, 14, 60, 45, TONE_NOISE, 0, 2},
+    SFX_BULLET_INTERCEPT: {900, 1400, 0, 0, 4, 4, 0, 30, TONE_PULSE2, TONE_MODE1, 2},
+    SFX_PLAYER_DEATH:     {500, 40, 0, 4, 30, 30, 80, 70, TONE_NOISE, 0, 3},
+    SFX_ENEMY_HIT:        {440, 220, 0, 0, 3, 3, 0, 30, TONE_PULSE2, TONE_MODE2, 2},
+    SFX_PLAYER_HURT:      {350, 110, 0, 2, 8, 8, 50, 50, TONE_PULSE1, TONE_MODE1, 3},
+    SFX_SHIELD_BLOCK:     {1200, 1100, 0, 0, 2, 3, 0, 25, TONE_PULSE2, TONE_MODE4, 1},
+    SFX_BOSS_SLAM:        {160, 40, 0, 3, 10, 20, 70, 55, TONE_NOISE, 0, 3},
+    SFX_PICKUP:           {520, 1040, 0, 0, 8, 6, 0, 40, TONE_PULSE1, TONE_MODE2, 2},
+}
+
+// Estado de cada canal: prioridade e quadros restantes do efeito atual
+var sfxChannels [4]struct {
+    priority uint8
+    timer    uint8
+}
+
+func playSfx(id int) {
+    if settings&SETTING_MUTE_SFX != 0 {
+        return
+    }
+    def := &sfxDefs[id]
+    ch := &sfxChannels[def.channel]
+    if ch.timer > 0 && def.priority < ch.priority {
+        return
+    }
+
+    ch.priority = def.priority
+    ch.timer = def.attack + def.decay + def.sustain + def.release
+
+    frequency := uint32(def.freqStart) | uint32(def.freqEnd)<<16
+    duration := uint32(def.attack)<<24 | uint32(def.decay)<<16 | uint32(def.sustain) | uint32(def.release)<<8
+    volume := uint32(def.peak)<<8 | uint32(def.volume)
+    sound.Tone(frequency, duration, volume, uint32(def.channel)|uint32(def.mode))
+}
+
+// Canal ainda ocupado por um efeito?
+func sfxBusy(channel int) bool {
+    return sfxChannels[channel].timer > 0
+}
+
+// Avança os timers dos canais (uma vez por quadro)
+func updateSfx() {
+    for i := 0; i < len(sfxChannels); i++ {
+        if sfxChannels[i].timer > 0 {
+            sfxChannels[i].timer--
+        }
+    }
+}
@@ -0,0 +1,14 @@
+package main
+
+// Os sprites vêm de assets/sprites; o atlas é gerado por cmd/spritegen
+//go:generate go run ../cmd/spritegen -in ../assets/sprites -out sprites_gen.go
+
+// Desenha um quadro do atlas. Cada nibble de colors pinta um índice da
+// paleta do sprite, a partir do índice 1; o índice 0 é sempre transparente.
+// Sprites de uma cor só recebem apenas a cor, ex.: 0x03.
+func drawSprite(id int, frame int, x, y int32, colors uint16, flags uint32) {
+    s := &spriteInfo[id]
+    setColors(colors << 4)
+    blitSub(spriteAtlas[:], x, y, int32(s.width), int32(s.height),
+        int32(s.x)+int32(frame)*int32(s.width), int32(s.y), SPRITE_ATLAS_WIDTH, BLIT_2BPP|flags)
+}
@@ -0,0 +1,123 @@
+// Code generated by cmd/spritegen; DO NOT EDIT.
+
+package main
+
+const (
+    SPRITE_ATLAS_WIDTH = 16
+    SPRITE_ATLAS_HEIGHT = 102
+    SPRITE_COUNT = 13
+
+    SPRITE_BOSS_CORE = 0
+    SPRITE_BOSS_CORE_WIDTH = 16
+    SPRITE_BOSS_CORE_HEIGHT = 14
+    SPRITE_BOSS_CORE_FRAMES = 1
+
+    SPRITE_BOSS_TURRET = 1
+    SPRITE_BOSS_TURRET_WIDTH = 10
+    SPRITE_BOSS_TURRET_HEIGHT = 6
+    SPRITE_BOSS_TURRET_FRAMES = 1
+
+    SPRITE_BULLET = 2
+    SPRITE_BULLET_WIDTH = 4
+    SPRITE_BULLET_HEIGHT = 2
+    SPRITE_BULLET_FRAMES = 1
+
+    SPRITE_FLYING_ENEMY = 3
+    SPRITE_FLYING_ENEMY_WIDTH = 8
+    SPRITE_FLYING_ENEMY_HEIGHT = 8
+    SPRITE_FLYING_ENEMY_FRAMES = 1
+
+    SPRITE_GROUND_ENEMY = 4
+    SPRITE_GROUND_ENEMY_WIDTH = 8
+    SPRITE_GROUND_ENEMY_HEIGHT = 12
+    SPRITE_GROUND_ENEMY_FRAMES = 1
+
+    SPRITE_HEART = 5
+    SPRITE_HEART_WIDTH = 5
+    SPRITE_HEART_HEIGHT = 5
+    SPRITE_HEART_FRAMES = 1
+
+    SPRITE_PICKUP = 6
+    SPRITE_PICKUP_WIDTH = 9
+    SPRITE_PICKUP_HEIGHT = 9
+    SPRITE_PICKUP_FRAMES = 1
+
+    SPRITE_PLAYER = 7
+    SPRITE_PLAYER_WIDTH = 8
+    SPRITE_PLAYER_HEIGHT = 12
+    SPRITE_PLAYER_FRAMES = 2
+
+    SPRITE_ROCK = 8
+    SPRITE_ROCK_WIDTH = 8
+    SPRITE_ROCK_HEIGHT = 8
+    SPRITE_ROCK_FRAMES = 1
+
+    SPRITE_SHIELD = 9
+    SPRITE_SHIELD_WIDTH = 2
+    SPRITE_SHIELD_HEIGHT = 10
+    SPRITE_SHIELD_FRAMES = 1
+
+    SPRITE_SPIKE = 10
+    SPRITE_SPIKE_WIDTH = 6
+    SPRITE_SPIKE_HEIGHT = 8
+    SPRITE_SPIKE_FRAMES = 1
+
+    SPRITE_WEAPON = 11
+    SPRITE_WEAPON_WIDTH = 6
+    SPRITE_WEAPON_HEIGHT = 3
+    SPRITE_WEAPON_FRAMES = 1
+
+    SPRITE_WEAPON_DIAG = 12
+    SPRITE_WEAPON_DIAG_WIDTH = 5
+    SPRITE_WEAPON_DIAG_HEIGHT = 5
+    SPRITE_WEAPON_DIAG_FRAMES = 1
+)
+
+// Posição no atlas e medidas de cada sprite
+var spriteInfo = [SPRITE_COUNT]struct {
+    x, y, width, height, frames uint8
+}{
+    SPRITE_BOSS_CORE: {0, 0, 16, 14, 1},
+    SPRITE_BOSS_TURRET: {0, 14, 10, 6, 1},
+    SPRITE_BULLET: {0, 20, 4, 2, 1},
+    SPRITE_FLYING_ENEMY: {0, 22, 8, 8, 1},
+    SPRITE_GROUND_ENEMY: {0, 30, 8, 12, 1},
+    SPRITE_HEART: {0, 42, 5, 5, 1},
+    SPRITE_PICKUP: {0, 47, 9, 9, 1},
+    SPRITE_PLAYER: {0, 56, 8, 12, 2},
+    SPRITE_ROCK: {0, 68, 8, 8, 1},
+    SPRITE_SHIELD: {0, 76, 2, 10, 1},
+    SPRITE_SPIKE: {0, 86, 6, 8, 1},
+    SPRITE_WEAPON: {0, 94, 6, 3, 1},
+    SPRITE_WEAPON_DIAG: {0, 97, 5, 5, 1},
+}
+
+// Atlas 2BPP, linhas de SPRITE_ATLAS_WIDTH pixels
+var spriteAtlas = [...]uint8{
+    0x00, 0x55, 0x55, 0x00, 0x05, 0xaa, 0xaa, 0x50, 0x1a, 0xaa, 0xaa, 0xa4, 0x6a, 0x95, 0x56, 0xa9,
+    0x6a, 0x7f, 0xfd, 0xa9, 0x69, 0xfe, 0xbf, 0x69, 0x69, 0xfa, 0xaf, 0x69, 0x69, 0xfa, 0xaf, 0x69,
+    0x69, 0xfe, 0xbf, 0x69, 0x6a, 0x7f, 0xfd, 0xa9, 0x6a, 0x95, 0x56, 0xa9, 0x1a, 0xaa, 0xaa, 0xa4,
+    0x05, 0xaa, 0xaa, 0x50, 0x00, 0x55, 0x55, 0x00, 0x00, 0x55, 0x50, 0x00, 0x00, 0x6a, 0x90, 0x00,
+    0x55, 0x6a, 0x90, 0x00, 0xaa, 0x6a, 0x90, 0x00, 0x00, 0x6a, 0x90, 0x00, 0x00, 0x55, 0x50, 0x00,
+    0x55, 0x00, 0x00, 0x00, 0x55, 0x00, 0x00, 0x00, 0x44, 0x11, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00,
+    0x55, 0x55, 0x00, 0x00, 0x54, 0x15, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00,
+    0x05, 0x50, 0x00, 0x00, 0x44, 0x11, 0x00, 0x00, 0x05, 0x50, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00,
+    0x54, 0x15, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00,
+    0x55, 0x55, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00,
+    0x55, 0x55, 0x00, 0x00, 0x44, 0x11, 0x00, 0x00, 0x11, 0x00, 0x00, 0x00, 0x55, 0x40, 0x00, 0x00,
+    0x55, 0x40, 0x00, 0x00, 0x15, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x05, 0x54, 0x00, 0x00,
+    0x1a, 0xa9, 0x00, 0x00, 0x6a, 0xaa, 0x40, 0x00, 0x6a, 0xaa, 0x40, 0x00, 0x6a, 0xaa, 0x40, 0x00,
+    0x6a, 0xaa, 0x40, 0x00, 0x6a, 0xaa, 0x40, 0x00, 0x1a, 0xa9, 0x00, 0x00, 0x05, 0x54, 0x00, 0x00,
+    0x01, 0x40, 0x01, 0x40, 0x05, 0x50, 0x05, 0x50, 0x01, 0x40, 0x01, 0x40, 0x05, 0x50, 0x05, 0x50,
+    0x15, 0x54, 0x15, 0x54, 0x05, 0x50, 0x05, 0x50, 0x05, 0x50, 0x05, 0x50, 0x05, 0x50, 0x05, 0x50,
+    0x15, 0x54, 0x15, 0x54, 0x15, 0x54, 0x15, 0x54, 0x14, 0x14, 0x50, 0x50, 0x54, 0x15, 0x50, 0x50,
+    0x05, 0x50, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00,
+    0x55, 0x55, 0x00, 0x00, 0x55, 0x55, 0x00, 0x00, 0x15, 0x54, 0x00, 0x00, 0x05, 0x50, 0x00, 0x00,
+    0x20, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00,
+    0x90, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00,
+    0x90, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x15, 0x00, 0x00, 0x00,
+    0x55, 0x40, 0x00, 0x00, 0x55, 0x40, 0x00, 0x00, 0x55, 0x40, 0x00, 0x00, 0x55, 0x40, 0x00, 0x00,
+    0x55, 0x40, 0x00, 0x00, 0x55, 0x40, 0x00, 0x00, 0x55, 0x50, 0x00, 0x00, 0x50, 0x00, 0x00, 0x00,
+    0x50, 0x00, 0x00, 0x00, 0x01, 0x40, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00,
+    0x50, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00,
+}
@@ -0,0 +1,146 @@
+package main
+
+// Terreno. O chão é uma fila de colunas de TERRAIN_TILE pixels, cada uma
+// com a altura da superfície (ou um buraco), uma inclinação, talvez uma
+// plataforma flutuante e o que aparece nela. proceduralSpawn() vai emendando
+// trechos (level.go) à frente da câmera; as colunas ficam num buffer
+// circular, e as que estão fora dele (muito atrás ou ainda não geradas)
+// valem como chão plano.
+//
+// A colisão é feita contra essas colunas: a superfície do chão é sólida
+// para baixo (a lateral de um degrau alto é parede) e as plataformas só
+// seguram quem vem de cima.
+const (
+    TERRAIN_TILE = 8
+    TERRAIN_TILE_SHIFT = 3
+    TERRAIN_COLUMNS = 128        // 1024 pixels no buffer
+    TERRAIN_LOOKAHEAD = 320      // Gerado além da borda direita da tela
+    TERRAIN_SAFE_START = SCREEN_WIDTH // Largada sempre no plano e vazia
+    TERRAIN_PIT = -1             // ground de uma coluna sem chão
+
+    TERRAIN_STEP = 4       // Desnível que se sobe andando (a rampa sobe isso por coluna)
+    TERRAIN_LEDGE = 16     // Degrau mais alto entre dois trechos
+    TERRAIN_MAX_RISE = 24  // Quanto o chão sobe acima de GROUND_Y
+    PLATFORM_THICKNESS = 3
+)
+
+type terrainColumn struct {
+    ground int16   // y da superfície na borda esquerda, ou TERRAIN_PIT
+    slope int8     // Quanto a superfície desce (positivo) até a próxima coluna
+    platform int16 // y do topo da plataforma; 0 = nenhuma
+    spawn uint8    // Letra da entidade (ver level.go); 0 = nenhuma
+    roll uint16    // Sorteio para a entidade (altura, tipo, power-up)
+}
+
+var (
+    terrain [TERRAIN_COLUMNS]terrainColumn
+    terrainEnd int32 = 0 // Primeira coluna ainda não gerada
+)
+
+// Largada plana e os primeiros trechos à frente
+func resetTerrain() {
+    terrainEnd = 0
+    resetLevel()
+    for terrainEnd < TERRAIN_SAFE_START/TERRAIN_TILE {
+        addColumn(terrainColumn{ground: GROUND_Y})
+    }
+    extendTerrain()
+}
+
+// Gera trechos até cobrir TERRAIN_LOOKAHEAD além da tela
+func extendTerrain() {
+    for terrainEnd*TERRAIN_TILE < cameraX+SCREEN_WIDTH+TERRAIN_LOOKAHEAD {
+        spawnChunk()
+    }
+}
+
+func addColumn(c terrainColumn) {
+    terrain[terrainEnd%TERRAIN_COLUMNS] = c
+    terrainEnd++
+}
+
+// Arena do chefe: tudo da coluna de x em diante vira chão plano e vazio
+func levelTerrain(x int32) {
+    for col := max32(x>>TERRAIN_TILE_SHIFT, terrainEnd-TERRAIN_COLUMNS); col < terrainEnd; col++ {
+        terrain[col%TERRAIN_COLUMNS] = terrainColumn{ground: GROUND_Y}
+    }
+    lastChunk = -1 // O trecho seguinte começa do chão plano
+}
+
+// Coluna do terreno; fora do buffer, chão plano
+func terrainColumnAt(col int32) terrainColumn {
+    if col < 0 || col >= terrainEnd || col < terrainEnd-TERRAIN_COLUMNS {
+        return terrainColumn{ground: GROUND_Y}
+    }
+    return terrain[col%TERRAIN_COLUMNS]
+}
+
+// Superfície do chão no pixel x, ou false no buraco
+func groundY(x int32) (int32, bool) {
+    col := x >> TERRAIN_TILE_SHIFT
+    c := terrainColumnAt(col)
+    if c.ground == TERRAIN_PIT {
+        return 0, false
+    }
+    return int32(c.ground) + int32(c.slope)*(x-col*TERRAIN_TILE)/TERRAIN_TILE, true
+}
+
+// Superfície mais alta do chão entre x e x+width, ou false se só há buraco
+func surfaceY(x, width int32) (int32, bool) {
+    top, found := int32(0), false
+    for i := int32(0); i < width; i++ {
+        if y, ok := groundY(x + i); ok && (!found || y < top) {
+            top, found = y, true
+        }
+    }
+    return top, found
+}
+
+// Superfície (chão ou plataforma) mais alta entre x e x+width cujo y está
+// entre top e bottom: onde pisa quem tem os pés nessa faixa
+func terrainFloor(x, width, top, bottom int32) (int32, bool) {
+    floor, found := int32(0), false
+    for i := int32(0); i < width; i++ {
+        if y, ok := groundY(x + i); ok && y >= top && y <= bottom && (!found || y < floor) {
+            floor, found = y, true
+        }
+        c := terrainColumnAt((x + i) >> TERRAIN_TILE_SHIFT)
+        if y := int32(c.platform); y != 0 && y >= top && y <= bottom && (!found || y < floor) {
+            floor, found = y, true
+        }
+    }
+    return floor, found
+}
+
+// Parede à frente de quem tem os pés em feet e anda para a direita:
+// devolve o x em que ele para encostado
+func terrainWall(x, width, feet int32) (int32, bool) {
+    front := x + width - 1
+    if y, ok := groundY(front); ok && y < feet-TERRAIN_STEP {
+        return (front>>TERRAIN_TILE_SHIFT)*TERRAIN_TILE - width, true
+    }
+    return x, false
+}
+
+func drawTerrain() {
+    first := cameraX >> TERRAIN_TILE_SHIFT
+    for col := first; col <= (cameraX+SCREEN_WIDTH)>>TERRAIN_TILE_SHIFT; col++ {
+        c := terrainColumnAt(col)
+        screenX := col*TERRAIN_TILE - cameraX
+        setColors(0x02)
+        if c.ground != TERRAIN_PIT {
+            if c.slope == 0 {
+                rect(screenX, int32(c.ground), TERRAIN_TILE, SCREEN_HEIGHT-int32(c.ground))
+            } else {
+                // Rampa: uma faixa de um pixel por x
+                for i := int32(0); i < TERRAIN_TILE; i++ {
+                    y, _ := groundY(col*TERRAIN_TILE + i)
+                    rect(screenX+i, y, 1, SCREEN_HEIGHT-y)
+                }
+            }
+        }
+        if c.platform != 0 {
+            rect(screenX, int32(c.platform), TERRAIN_TILE, PLATFORM_THICKNESS)
+        }
+    }
+}
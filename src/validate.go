@@ -0,0 +1,170 @@
+package main
+
+// Validador da fase. Cada trecho que spawnChunk() emenda é conferido
+// simulando o jogador com a mesma física do jogo (moveBody) e a velocidade
+// e o pulo da dificuldade atual: procura-se alguma sequência de passos e
+// pulos que atravesse o trecho sem cair num buraco nem encostar numa pedra
+// ou num espeto. Inimigos não contam, porque dá para derrubá-los a tiro, mas
+// o primeiro tiro de um terrestre sim: à frente de cada coluna 'g' fica uma
+// faixa proibida na altura do tiro, do tamanho do trecho em que ele cruza o
+// caminho do jogador. Assim é recusado um espeto tão perto dessa faixa que
+// não sobra chão para pousar entre os dois pulos.
+//
+// A busca anda pelas posições em que o jogador está no chão. De cada uma
+// ele pode dar mais um passo ou pular segurando o botão por um dos tempos
+// de validateHolds; o pulo de coyote fica de fora, então o validador é um
+// pouco mais exigente que o jogo.
+const (
+    VALIDATE_JOIN = 3                        // Colunas do trecho anterior incluídas (a emenda)
+    VALIDATE_PIXELS = 32 * TERRAIN_TILE      // Maior janela conferida de uma vez
+    VALIDATE_MAX_FRAMES = 120                // Um pulo nunca fica tanto no ar
+    VALIDATE_MAX_HAZARDS = 16
+    VALIDATE_SHOT_REACH = TERRAIN_TILE       // Comprimento da faixa do tiro
+)
+
+var validateHolds = [...]uint8{1, PLAYER_JUMP_HOLD_FRAMES / 2, PLAYER_JUMP_HOLD_FRAMES}
+
+// Resultado de um movimento simulado
+const (
+    ARC_DEAD = iota
+    ARC_LANDED
+    ARC_GOAL
+)
+
+// Posição em que o jogador está no chão (o resto do corpo é o de quem anda
+// na velocidade atual) e o próximo movimento a tentar dali
+type groundState struct {
+    x, y int16
+    subX uint8
+    move int8
+}
+
+var (
+    // Caixas de colisão das pedras e espetos da janela conferida
+    hazardBoxes [VALIDATE_MAX_HAZARDS][4]int32
+    hazardCount int
+
+    // Busca em profundidade: bit 0 = já esteve no chão nesse x, bit 1 =
+    // numa plataforma acima do chão da coluna
+    visited [VALIDATE_PIXELS]uint8
+    stack [2 * VALIDATE_PIXELS]groundState
+)
+
+// O terreno entre a coluna from e terrainEnd é atravessável? Começa parado
+// no chão um pouco antes de from, para conferir também a emenda; os
+// obstáculos de antes de from já foram conferidos com o trecho anterior
+func terrainPassable(from int32) bool {
+    start := from - VALIDATE_JOIN
+    for start < from-1 && terrainColumnAt(start).ground == TERRAIN_PIT {
+        start++
+    }
+    baseX := start * TERRAIN_TILE
+    goalX := terrainEnd*TERRAIN_TILE - PLAYER_WIDTH
+    if goalX-baseX >= VALIDATE_PIXELS {
+        return false // Trecho comprido demais para a janela
+    }
+
+    hazardCount = 0
+    for col := from; col < terrainEnd && hazardCount < VALIDATE_MAX_HAZARDS; col++ {
+        if e, ok := columnObstacle(col); ok {
+            x, y, w, h := entityHitbox(&e)
+            hazardBoxes[hazardCount] = [4]int32{x, y, w, h}
+            hazardCount++
+        }
+        if terrainColumnAt(col).spawn == 'g' && hazardCount < VALIDATE_MAX_HAZARDS {
+            hazardBoxes[hazardCount] = shotBand(col)
+            hazardCount++
+        }
+    }
+
+    for i := range visited {
+        visited[i] = 0
+    }
+    top := 0
+
+    ground, ok := surfaceY(baseX, PLAYER_WIDTH)
+    if !ok {
+        return false
+    }
+    stack[top] = groundState{x: int16(baseX), y: int16(ground - PLAYER_HEIGHT), move: -1}
+    top++
+    visited[0] = 1
+    for top > 0 {
+        // Tenta o passo (-1) antes dos pulos, e só volta para tentar um pulo
+        // quando o caminho andando não chegou; no plano a busca vai direto
+        s := &stack[top-1]
+        if int(s.move) >= len(validateHolds) {
+            top--
+            continue
+        }
+        b := playerBody{
+            x: int32(s.x), y: int32(s.y), subX: s.subX,
+            velX: currentPlayerSpeed, coyote: PLAYER_COYOTE_FRAMES, flags: 0x03,
+        }
+        move := int(s.move)
+        s.move++
+        switch simulateMove(&b, move, goalX) {
+        case ARC_GOAL:
+            return true
+        case ARC_LANDED:
+            i := b.x - baseX
+            if i < 0 || i >= VALIDATE_PIXELS {
+                continue
+            }
+            bit := uint8(1)
+            if ground, ok := surfaceY(b.x, PLAYER_WIDTH); !ok || b.y+PLAYER_HEIGHT < ground {
+                bit = 2
+            }
+            if visited[i]&bit == 0 {
+                visited[i] |= bit
+                stack[top] = groundState{int16(b.x), int16(b.y), b.subX, -1}
+                top++
+            }
+        }
+    }
+    return false
+}
+
+// Simula um passo (move -1) ou um pulo até o jogador pousar, morrer ou
+// chegar ao fim do trecho
+func simulateMove(b *playerBody, move int, goalX int32) int {
+    hold := -1
+    if move >= 0 {
+        hold = int(validateHolds[move])
+        startJump(b)
+    }
+    for frame := 0; frame < VALIDATE_MAX_FRAMES; frame++ {
+        if frame == hold {
+            releaseJump(b)
+        }
+        landed := moveBody(b)
+        if b.y > SCREEN_HEIGHT || touchesHazard(b) {
+            return ARC_DEAD
+        }
+        if b.x >= goalX {
+            return ARC_GOAL
+        }
+        if landed || hold < 0 && (b.flags&0x01) != 0 {
+            return ARC_LANDED
+        }
+    }
+    return ARC_DEAD
+}
+
+// Faixa por onde passa o primeiro tiro do terrestre da coluna: sai de
+// e.x-2, e.y+6 (updateEntityAI) e vem para a esquerda
+func shotBand(col int32) [4]int32 {
+    x := col*TERRAIN_TILE - 2
+    y := columnSurface(col) - 12 + 6
+    return [4]int32{x - VALIDATE_SHOT_REACH, y, VALIDATE_SHOT_REACH + ENEMY_BULLET_WIDTH, ENEMY_BULLET_HEIGHT}
+}
+
+func touchesHazard(b *playerBody) bool {
+    for i := 0; i < hazardCount; i++ {
+        h := &hazardBoxes[i]
+        if collision(b.x, b.y, PLAYER_WIDTH, PLAYER_HEIGHT, h[0], h[1], h[2], h[3]) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,4 @@
+// Package w4 contém as ligações da API do WASM-4. As funções são importadas
+// do runtime e só existem no build do TinyGo (tag tinygo.wasm); no host o
+// pacote fica vazio e o jogo usa o backend em memória.
+package w4
@@ -1,3 +1,5 @@
+//go:build tinygo.wasm
+
 //
 // WASM-4: https://wasm4.org/docs
 
@@ -62,11 +64,11 @@ const (
 // └───────────────────────────────────────────────────────────────────────────┘
 
 /** Copies pixels to the framebuffer. */
-//go:export blit
+//go:wasmimport env blit
 func Blit(sprite *byte, x int, y int, width uint, height uint, flags uint)
 
 /** Copies a subregion within a larger sprite atlas to the framebuffer. */
-//go:export blitSub
+//go:wasmimport env blitSub
 func BlitSub(sprite *byte, x int, y int, width uint, height uint,
 	srcX uint, srcY uint, stride int, flags uint)
 
@@ -79,27 +81,27 @@ const (
 )
 
 /** Draws a line between two points. */
-//go:export line
+//go:wasmimport env line
 func Line(x1 int, y1 int, x2 int, y2 int)
 
 /** Draws a horizontal line. */
-//go:export hline
+//go:wasmimport env hline
 func HLine(x int, y int, len uint)
 
 /** Draws a vertical line. */
-//go:export vline
+//go:wasmimport env vline
 func VLine(x int, y int, len uint)
 
 /** Draws an oval (or circle). */
-//go:export oval
+//go:wasmimport env oval
 func Oval(x int, y int, width uint, height uint)
 
 /** Draws a rectangle. */
-//go:export rect
+//go:wasmimport env rect
 func Rect(x int, y int, width uint, height uint)
 
 /** Draws text using the built-in system font. */
-//go:export textUtf8
+//go:wasmimport env textUtf8
 func Text(text string, x int, y int)
 
 // ┌───────────────────────────────────────────────────────────────────────────┐
@@ -109,7 +111,7 @@ func Text(text string, x int, y int)
 // └───────────────────────────────────────────────────────────────────────────┘
 
 /** Plays a sound tone. */
-//go:export tone
+//go:wasmimport env tone
 func Tone(frequency uint, duration uint, volume uint, flags uint)
 
 const (
@@ -133,11 +135,11 @@ const (
 // └───────────────────────────────────────────────────────────────────────────┘
 
 /** Reads up to `size` bytes from persistent storage into the pointer `destPtr`. */
-//go:export diskr
+//go:wasmimport env diskr
 func DiskR(ptr unsafe.Pointer, count uint) uint
 
 /** Writes up to `size` bytes from the pointer `srcPtr` into persistent storage. */
-//go:export diskw
+//go:wasmimport env diskw
 func DiskW(src unsafe.Pointer, count uint) uint
 
 // ┌───────────────────────────────────────────────────────────────────────────┐
@@ -147,7 +149,7 @@ func DiskW(src unsafe.Pointer, count uint) uint
 // └───────────────────────────────────────────────────────────────────────────┘
 
 /** Prints a message to the debug console. */
-//go:export traceUtf8
+//go:wasmimport env traceUtf8
 func Trace(str string)
 
 // TinyGo requires a main function, so provide one